@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// redactedValue replaces secrets in printed configuration
+const redactedValue = "********"
+
+// secretFieldSuffixes mark configuration fields whose values are never printed
+var secretFieldSuffixes = []string{"password", "secret", "token", "apikey"}
+
+// newConfigCmd creates the command group that inspects configuration
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the effective configuration",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, line := range configLines("", reflect.ValueOf(*config.LoadConfig())) {
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// configLines flattens a configuration struct into "Section.Field=value" lines
+func configLines(prefix string, v reflect.Value) []string {
+	var lines []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + field.Name
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}) {
+			lines = append(lines, configLines(name+".", value)...)
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s=%s", name, formatConfigValue(field.Name, value)))
+	}
+	return lines
+}
+
+// formatConfigValue renders a configuration value, redacting secrets and URL credentials
+func formatConfigValue(fieldName string, value reflect.Value) string {
+	if isSecretField(fieldName) {
+		if value.IsZero() {
+			return ""
+		}
+		return redactedValue
+	}
+
+	switch v := value.Interface().(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case time.Duration:
+		return v.String()
+	case string:
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isSecretField reports whether a configuration field holds a secret
+func isSecretField(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range secretFieldSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,54 +2,45 @@ package main
 
 import (
 	"os"
-	"os/signal"
-	"syscall"
 
-	"github.com/albuquerquewizard/monorepo/backend/internal/app"
+	"github.com/albuquerquewizard/monorepo/backend/internal/buildinfo"
 	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/logging"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
 )
 
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
-
-	// Setup logger
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	logger := log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-
-	// Set log level
-	level, err := zerolog.ParseLevel(cfg.Log.Level)
-	if err != nil {
-		level = zerolog.InfoLevel
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
 	}
-	zerolog.SetGlobalLevel(level)
-
-	logger.Info().Msgf("🚀 Starting %s in %s mode", cfg.App.Name, cfg.App.Env)
-	logger.Info().Msgf("📡 Server will be available at http://localhost:%s", cfg.App.Port)
-
-	// Create and initialize application
-	application := app.NewApp(cfg, logger)
-
-	// Start server in a goroutine
-	go func() {
-		if err := application.Start(); err != nil {
-			logger.Fatal().Err(err).Msg("Failed to start server")
-		}
-	}()
+}
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+// newRootCmd builds the command tree. Running without a subcommand starts the server.
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "go-boilerplate",
+		Short:        "Go boilerplate API server and management commands",
+		SilenceUsage: true,
+		Version:      buildinfo.Get().String(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe()
+		},
+	}
 
-	logger.Info().Msg("🛑 Shutting down server...")
+	root.AddCommand(
+		newServeCmd(),
+		newMigrateCmd(),
+		newSeedCmd(),
+		newUserCmd(),
+		newRoutesCmd(),
+		newConfigCmd(),
+	)
 
-	// Gracefully shutdown the server
-	if err := application.Shutdown(); err != nil {
-		logger.Error().Err(err).Msg("Error during server shutdown")
-	}
+	return root
+}
 
-	logger.Info().Msg("✅ Server stopped")
+// newLogger builds the application logger in the configured level and format, redacting secrets
+func newLogger(cfg *config.Config) zerolog.Logger {
+	return logging.New(cfg.Log.Level, cfg.Log.Format, cfg.Log.RedactFields)
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/database"
+	"github.com/spf13/cobra"
+)
+
+// newMigrateCmd creates the command group that manages versioned migrations
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database schema migrations",
+	}
+
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back the most recently applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if steps < 1 {
+				return errors.New("--steps must be at least 1")
+			}
+
+			migrator, closeDB, err := openMigrator()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			rolledBack, err := migrator.Down(cmd.Context(), steps)
+			for _, m := range rolledBack {
+				fmt.Fprintf(cmd.OutOrStdout(), "Rolled back %06d_%s\n", m.Version, m.Name)
+			}
+			return err
+		},
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "number of migrations to roll back")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply all pending migrations",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				migrator, closeDB, err := openMigrator()
+				if err != nil {
+					return err
+				}
+				defer closeDB()
+
+				applied, err := migrator.Up(cmd.Context())
+				for _, m := range applied {
+					fmt.Fprintf(cmd.OutOrStdout(), "Applied %06d_%s\n", m.Version, m.Name)
+				}
+				if err == nil && len(applied) == 0 {
+					fmt.Fprintln(cmd.OutOrStdout(), "No pending migrations")
+				}
+				return err
+			},
+		},
+		down,
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show which migrations have been applied",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				migrator, closeDB, err := openMigrator()
+				if err != nil {
+					return err
+				}
+				defer closeDB()
+
+				statuses, err := migrator.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+				for _, s := range statuses {
+					status, appliedAt := "pending", "-"
+					if s.Applied {
+						status, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+					}
+					fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+				}
+				return w.Flush()
+			},
+		},
+	)
+
+	return cmd
+}
+
+// openMigrator connects to the configured database and returns a migrator for it
+func openMigrator() (*database.Migrator, func(), error) {
+	cfg := config.LoadConfig()
+	db := config.NewDatabase(cfg, newLogger(cfg))
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+
+	migrator, err := database.NewMigrator(sqlDB)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+
+	return migrator, func() { _ = db.Close() }, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/app"
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newRoutesCmd creates the command that prints the route table
+func newRoutesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "routes",
+		Short: "Print the HTTP route table",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.LoadConfig()
+			// No tokens are signed while listing routes, so a missing JWT_SECRET is fine
+			if cfg.JWT.Secret == "" {
+				cfg.JWT.Secret = "unused-routes-command-secret"
+			}
+			// Only warnings and errors are logged, so a fatal setup error is not silent
+			logger := newLogger(cfg).Level(zerolog.WarnLevel)
+
+			// Registering routes never queries the database, so no connection is opened
+			application := app.New(cfg, logger, &config.Database{})
+
+			routes := application.FiberApp.GetRoutes(true)
+			sort.SliceStable(routes, func(i, j int) bool {
+				if routes[i].Path != routes[j].Path {
+					return routes[i].Path < routes[j].Path
+				}
+				return routes[i].Method < routes[j].Method
+			})
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "METHOD\tPATH\tHANDLERS")
+			for _, route := range routes {
+				// Fiber registers a HEAD route alongside every GET route
+				if route.Method == fiber.MethodHead {
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\n", route.Method, route.Path, len(route.Handlers))
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// newSeedCmd creates the command that seeds initial data
+func newSeedCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "seed",
+		Short: "Seed the database with initial data such as the default roles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.LoadConfig()
+			logger := newLogger(cfg)
+			db := config.NewDatabase(cfg, logger)
+			defer db.Close()
+
+			return models.SeedData(db.DB, logger)
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/app"
+	"github.com/albuquerquewizard/monorepo/backend/internal/buildinfo"
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// newServeCmd creates the command that runs the HTTP server
+func newServeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe()
+		},
+	}
+}
+
+// runServe starts the server and blocks until it is interrupted
+func runServe() error {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Setup logger
+	logger := newLogger(cfg)
+
+	logger.Info().Msgf("🚀 Starting %s %s in %s mode", cfg.App.Name, buildinfo.Get(), cfg.App.Env)
+	logger.Info().Msgf("📡 Server will be available at http://localhost:%s", cfg.App.Port)
+
+	// Create and initialize application
+	application := app.NewApp(cfg, logger)
+
+	// Start server in a goroutine; a failure to listen shuts down like a signal does
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- application.Start()
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	var startErr error
+	select {
+	case sig := <-quit:
+		logger.Info().Str("signal", sig.String()).Msg("🛑 Shutting down server...")
+	case startErr = <-serverErr:
+		if startErr != nil {
+			logger.Error().Err(startErr).Msg("Failed to start server")
+		}
+		logger.Info().Msg("🛑 Shutting down server...")
+	}
+
+	// Gracefully shutdown the server
+	if err := application.Shutdown(); err != nil {
+		logger.Error().Err(err).Msg("Error during server shutdown")
+	}
+
+	logger.Info().Msg("✅ Server stopped")
+	return startErr
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/middleware"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// newUserCmd creates the command group that manages user accounts
+func newUserCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage user accounts",
+	}
+
+	cmd.AddCommand(newUserCreateCmd(), newUserSetPasswordCmd(), newUserPurgeCmd())
+	return cmd
+}
+
+// newUserCreateCmd creates the command that creates a user, optionally as an admin
+func newUserCreateCmd() *cobra.Command {
+	var (
+		user     models.User
+		password string
+		admin    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user account",
+		Long:  "Create a user account. If --password is omitted the password is read from stdin.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svcs, closeDB := openServices()
+			defer closeDB()
+
+			var err error
+			if user.Password, err = readPassword(cmd, password); err != nil {
+				return err
+			}
+			user.IsActive = true
+
+			var roles []string
+			if admin {
+				roles = append(roles, models.RoleAdmin)
+			}
+			if err := svcs.User.CreateUser(cmd.Context(), &user, roles...); err != nil {
+				if admin && errors.Is(err, repositories.ErrRoleNotFound) {
+					return fmt.Errorf("granting %q failed (has the database been seeded?): %w", models.RoleAdmin, err)
+				}
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Created user %q with ID %d\n", user.Username, user.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&user.Username, "username", "", "username of the new account")
+	cmd.Flags().StringVar(&password, "password", "", "password of the new account")
+	cmd.Flags().StringVar(&user.FirstName, "first-name", "", "first name")
+	cmd.Flags().StringVar(&user.LastName, "last-name", "", "last name")
+	cmd.Flags().BoolVar(&admin, "admin", false, "grant the admin role")
+	_ = cmd.MarkFlagRequired("username")
+
+	return cmd
+}
+
+// newUserSetPasswordCmd creates the command that resets a user's password
+func newUserSetPasswordCmd() *cobra.Command {
+	var username, password string
+
+	cmd := &cobra.Command{
+		Use:   "set-password",
+		Short: "Set a user's password",
+		Long:  "Set a user's password. If --password is omitted the password is read from stdin.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svcs, closeDB := openServices()
+			defer closeDB()
+
+			newPassword, err := readPassword(cmd, password)
+			if err != nil {
+				return err
+			}
+
+			user, err := svcs.User.GetUserByUsername(cmd.Context(), username)
+			if err != nil {
+				return err
+			}
+
+			if err := svcs.User.ChangePassword(cmd.Context(), user.ID, newPassword); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Password updated for %q\n", user.Username)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&username, "username", "", "username of the account")
+	cmd.Flags().StringVar(&password, "password", "", "new password")
+	_ = cmd.MarkFlagRequired("username")
+
+	return cmd
+}
+
+// newUserPurgeCmd creates the command that permanently deletes long soft-deleted users
+func newUserPurgeCmd() *cobra.Command {
+	var days int
+
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Permanently delete users that were soft-deleted long ago",
+		Long: "Permanently delete users that were soft-deleted more than --days days ago. " +
+			"Without --days the PURGE_AFTER_DAYS setting is used.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("days") {
+				days = config.LoadConfig().Purge.AfterDays
+			}
+			if days <= 0 {
+				return errors.New("purging is disabled, pass --days to purge anyway")
+			}
+
+			svcs, closeDB := openServices()
+			defer closeDB()
+
+			before := time.Now().Add(-config.PurgeConfig{AfterDays: days}.Retention())
+			purged, err := svcs.User.PurgeDeletedUsers(cmd.Context(), before)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Purged %d user(s) deleted more than %d days ago\n", purged, days)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", 0, "purge users deleted more than this many days ago")
+
+	return cmd
+}
+
+// openServices connects to the configured database and builds the service layer on top of it
+func openServices() (*services.Services, func()) {
+	cfg := config.LoadConfig()
+	db := config.NewDatabase(cfg, newLogger(cfg))
+
+	svcs := services.NewServices(repositories.NewRepositories(db.DB), cfg, middleware.Validate)
+	return svcs, func() { _ = db.Close() }
+}
+
+// readPassword returns the flag value if set, otherwise reads one line from stdin
+func readPassword(cmd *cobra.Command, flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		cmd.PrintErr("Password: ")
+	}
+
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given on --password or stdin")
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password must not be empty")
+	}
+	return password, nil
+}
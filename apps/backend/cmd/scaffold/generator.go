@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.tmpl"))
+
+// migrationFilePattern matches versioned migration files such as 000001_create_users.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_[a-z0-9_]+\.(up|down)\.sql$`)
+
+// modulePattern reads the module path from go.mod
+var modulePattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// generator renders a resource into a backend module
+type generator struct {
+	dir      string
+	resource *resource
+}
+
+// file is a new file to create
+type file struct {
+	Path    string
+	Content []byte
+}
+
+// edit replaces the content of an existing file
+type edit struct {
+	Path    string
+	Content []byte
+}
+
+// plan is the complete set of changes for a resource, computed before anything is written
+type plan struct {
+	dir   string
+	Files []file
+	Edits []edit
+}
+
+// wiring describes code inserted above the "Add more ... here" markers of a file.
+// Inserts[i] goes above the i-th occurrence of Marker.
+type wiring struct {
+	Path    string
+	Marker  string
+	Inserts []string
+	// Exists detects, outside comments, that the resource is already wired in
+	Exists string
+}
+
+// newGenerator checks that dir is the backend module and reads its module path
+func newGenerator(dir string, r *resource) (*generator, error) {
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not the backend module directory: %w", dir, err)
+	}
+
+	match := modulePattern.FindSubmatch(goMod)
+	if match == nil {
+		return nil, errors.New("go.mod does not declare a module path")
+	}
+	r.ModulePath = string(match[1])
+
+	return &generator{dir: dir, resource: r}, nil
+}
+
+// Plan renders every file and wiring change, failing if a file to create already exists
+func (g *generator) Plan() (*plan, error) {
+	r := g.resource
+	version, err := g.nextMigrationVersion()
+	if err != nil {
+		return nil, err
+	}
+	migration := fmt.Sprintf("internal/database/migrations/%06d_create_%s", version, r.Table)
+
+	p := &plan{dir: g.dir}
+	outputs := []struct {
+		template string
+		path     string
+	}{
+		{"model.go.tmpl", "internal/models/" + r.Snake + ".go"},
+		{"migration.up.sql.tmpl", migration + ".up.sql"},
+		{"migration.down.sql.tmpl", migration + ".down.sql"},
+		{"request.go.tmpl", "internal/dto/" + r.Snake + ".go"},
+		{"repository.go.tmpl", "internal/repositories/" + r.Snake + "_repository.go"},
+		{"service.go.tmpl", "internal/services/" + r.Snake + "_service.go"},
+		{"controller.go.tmpl", "internal/controllers/" + r.Snake + "_controller.go"},
+		{"controller_test.go.tmpl", "internal/controllers/" + r.Snake + "_controller_test.go"},
+	}
+	for _, output := range outputs {
+		if _, err := os.Stat(filepath.Join(g.dir, output.path)); err == nil {
+			return nil, fmt.Errorf("%s already exists, refusing to overwrite it", output.path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		content, err := g.render(output.template, output.path)
+		if err != nil {
+			return nil, err
+		}
+		p.Files = append(p.Files, file{Path: output.path, Content: content})
+	}
+
+	for _, w := range g.wirings() {
+		content, err := g.wire(w)
+		if err != nil {
+			return nil, err
+		}
+		p.Edits = append(p.Edits, edit{Path: w.Path, Content: content})
+	}
+
+	return p, nil
+}
+
+// Apply writes the plan. New files are created exclusively so that a file
+// appearing after Plan ran is still never overwritten.
+func (p *plan) Apply() error {
+	for _, f := range p.Files {
+		path := filepath.Join(p.dir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(f.Path), err)
+		}
+		out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", f.Path, err)
+		}
+		_, err = out.Write(f.Content)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+
+	for _, e := range p.Edits {
+		if err := os.WriteFile(filepath.Join(p.dir, e.Path), e.Content, 0o644); err != nil {
+			return fmt.Errorf("updating %s: %w", e.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// wirings lists the registrations that hook the resource into the application
+func (g *generator) wirings() []wiring {
+	r := g.resource
+	return []wiring{
+		{
+			Path:    "internal/models/models.go",
+			Marker:  "// Add more models here",
+			Inserts: []string{"&" + r.Name + "{},"},
+			Exists:  "&" + r.Name + "{},",
+		},
+		{
+			Path:   "internal/repositories/repositories.go",
+			Marker: "// Add more repositories here",
+			Inserts: []string{
+				r.Name + " " + r.Name + "Repository",
+				r.Name + ": New" + r.Name + "Repository(db),",
+			},
+			Exists: "New" + r.Name + "Repository(",
+		},
+		{
+			Path:   "internal/services/services.go",
+			Marker: "// Add more services here",
+			Inserts: []string{
+				r.Name + " " + r.Name + "Service",
+				r.Name + ": New" + r.Name + "Service(repos." + r.Name + ", validate),",
+			},
+			Exists: "New" + r.Name + "Service(",
+		},
+		{
+			Path:   "internal/controllers/controllers.go",
+			Marker: "// Add more controllers here",
+			Inserts: []string{
+				r.Name + " *" + r.Name + "Controller",
+				r.Name + ": New" + r.Name + "Controller(services." + r.Name + ", cursors),",
+			},
+			Exists: "New" + r.Name + "Controller(",
+		},
+		{
+			Path:   "internal/routes/routes.go",
+			Marker: "// TODO: Add more API routes here",
+			Inserts: []string{
+				"// " + r.Title() + " routes\n" +
+					"controllers." + r.Name + ".Mount(api.Group(\"" + r.Path + "\"), models." + r.Name + "Permissions, requireAuth, perUser)\n\n",
+			},
+			Exists: "controllers." + r.Name + ".Mount(",
+		},
+	}
+}
+
+// wire inserts a wiring's code above its markers, matching their indentation
+func (g *generator) wire(w wiring) ([]byte, error) {
+	src, err := os.ReadFile(filepath.Join(g.dir, w.Path))
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.SplitAfter(string(src), "\n")
+	for _, line := range lines {
+		code := strings.TrimSpace(line)
+		if !strings.HasPrefix(code, "//") && strings.Contains(code, w.Exists) {
+			return nil, fmt.Errorf("%s already registers %s", w.Path, g.resource.Name)
+		}
+	}
+
+	var out strings.Builder
+	found := 0
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, w.Marker) && found < len(w.Inserts) {
+			indent := line[:len(line)-len(trimmed)]
+			for _, insert := range strings.SplitAfter(w.Inserts[found], "\n") {
+				if strings.TrimSpace(insert) == "" {
+					out.WriteString(insert)
+					continue
+				}
+				out.WriteString(indent + strings.TrimSuffix(insert, "\n") + "\n")
+			}
+			found++
+		}
+		out.WriteString(line)
+	}
+	if found != len(w.Inserts) {
+		return nil, fmt.Errorf("%s: expected %d %q marker(s), found %d",
+			w.Path, len(w.Inserts), w.Marker, found)
+	}
+
+	formatted, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", w.Path, err)
+	}
+	return formatted, nil
+}
+
+// render executes a template, formatting Go output
+func (g *generator) render(name, path string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, g.resource); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", path, err)
+	}
+	if filepath.Ext(path) != ".go" {
+		return buf.Bytes(), nil
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", path, err)
+	}
+	return formatted, nil
+}
+
+// nextMigrationVersion returns the version following the newest migration
+func (g *generator) nextMigrationVersion() (int64, error) {
+	entries, err := os.ReadDir(filepath.Join(g.dir, "internal/database/migrations"))
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		if strings.Contains(entry.Name(), "_create_"+g.resource.Table+".") {
+			return 0, fmt.Errorf("migration %s already creates %s", entry.Name(), g.resource.Table)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		latest = max(latest, version)
+	}
+	return latest + 1, nil
+}
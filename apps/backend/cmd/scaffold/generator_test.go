@@ -0,0 +1,366 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testModuleFiles is a minimal backend module holding every wiring marker
+var testModuleFiles = map[string]string{
+	"go.mod": "module example.com/backend\n\ngo 1.25\n",
+	"internal/models/models.go": `package models
+
+func Models() []interface{} {
+	return []interface{}{
+		// Add more models here
+	}
+}
+`,
+	"internal/repositories/repositories.go": `package repositories
+
+type Repositories struct {
+	// Add more repositories here
+}
+
+func NewRepositories() *Repositories {
+	return &Repositories{
+		// Add more repositories here
+	}
+}
+`,
+	"internal/services/services.go": `package services
+
+type Services struct {
+	// Add more services here
+}
+
+func NewServices() *Services {
+	return &Services{
+		// Add more services here
+	}
+}
+`,
+	"internal/controllers/controllers.go": `package controllers
+
+type Controllers struct {
+	// Add more controllers here
+}
+
+func NewControllers() *Controllers {
+	return &Controllers{
+		// Add more controllers here
+	}
+}
+`,
+	"internal/routes/routes.go": `package routes
+
+func SetupRoutes() {
+	// TODO: Add more API routes here
+}
+`,
+	"internal/database/migrations/000001_create_users.up.sql":   "",
+	"internal/database/migrations/000001_create_users.down.sql": "",
+	"internal/database/migrations/000003_add_index.up.sql":      "",
+	"internal/database/migrations/000003_add_index.down.sql":    "",
+	"internal/database/migrations/README.md":                    "",
+}
+
+// newTestModule writes the minimal module to a temporary directory
+func newTestModule(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for path, content := range testModuleFiles {
+		writeFile(t, filepath.Join(dir, path), content)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// planResource parses a resource and plans it into dir
+func planResource(t *testing.T, dir, name string, fields ...string) (*plan, error) {
+	t.Helper()
+
+	r, err := parseResource(name, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGenerator(dir, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.Plan()
+}
+
+func TestPlanWiresResourceAtMarkers(t *testing.T) {
+	dir := newTestModule(t)
+
+	p, err := planResource(t, dir, "OrderItem", "name:string:required")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if err := p.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	for _, path := range []string{
+		"internal/models/order_item.go",
+		"internal/dto/order_item.go",
+		"internal/repositories/order_item_repository.go",
+		"internal/services/order_item_service.go",
+		"internal/controllers/order_item_controller.go",
+		"internal/controllers/order_item_controller_test.go",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("%s was not created: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		marker  string
+		inserts []string
+	}{
+		{"internal/models/models.go", "// Add more models here", []string{"&OrderItem{},"}},
+		{"internal/repositories/repositories.go", "// Add more repositories here",
+			[]string{"OrderItem OrderItemRepository", "OrderItem: NewOrderItemRepository(db),"}},
+		{"internal/services/services.go", "// Add more services here",
+			[]string{"OrderItem OrderItemService", "OrderItem: NewOrderItemService(repos.OrderItem, validate),"}},
+		{"internal/controllers/controllers.go", "// Add more controllers here",
+			[]string{"OrderItem *OrderItemController", "OrderItem: NewOrderItemController(services.OrderItem, cursors),"}},
+		{"internal/routes/routes.go", "// TODO: Add more API routes here",
+			[]string{`controllers.OrderItem.Mount(api.Group("/order-items"), models.OrderItemPermissions, requireAuth, perUser)`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			content := readFile(t, filepath.Join(dir, tt.path))
+			// Each insert sits directly above its marker, in order
+			parts := strings.Split(content, tt.marker)
+			if len(parts)-1 != len(tt.inserts) {
+				t.Fatalf("found %d markers, want %d:\n%s", len(parts)-1, len(tt.inserts), content)
+			}
+			for i, insert := range tt.inserts {
+				lines := strings.Split(strings.TrimRight(parts[i], " \t\n"), "\n")
+				if last := strings.TrimSpace(lines[len(lines)-1]); last != insert {
+					t.Errorf("line above marker %d = %q, want %q", i+1, last, insert)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanNumbersMigrationAfterNewest(t *testing.T) {
+	dir := newTestModule(t)
+
+	p, err := planResource(t, dir, "Product", "name:string")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	var migrations []string
+	for _, f := range p.Files {
+		if strings.HasPrefix(f.Path, "internal/database/migrations/") {
+			migrations = append(migrations, filepath.Base(f.Path))
+		}
+	}
+	want := []string{"000004_create_products.up.sql", "000004_create_products.down.sql"}
+	if strings.Join(migrations, ",") != strings.Join(want, ",") {
+		t.Errorf("migrations = %v, want %v", migrations, want)
+	}
+
+	up := string(p.Files[1].Content)
+	for _, permission := range []string{"products:create", "products:read", "products:update", "products:delete"} {
+		if !strings.Contains(up, "'"+permission+"'") {
+			t.Errorf("migration does not seed %s:\n%s", permission, up)
+		}
+	}
+}
+
+func TestPlanRefusesToOverwrite(t *testing.T) {
+	dir := newTestModule(t)
+	existing := filepath.Join(dir, "internal/services/product_service.go")
+	writeFile(t, existing, "package services\n")
+
+	_, err := planResource(t, dir, "Product", "name:string")
+	if err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("Plan() error = %v, want a refusal to overwrite", err)
+	}
+	if content := readFile(t, existing); content != "package services\n" {
+		t.Errorf("existing file was modified: %q", content)
+	}
+}
+
+func TestApplyRefusesToOverwriteFilesCreatedAfterPlan(t *testing.T) {
+	dir := newTestModule(t)
+
+	p, err := planResource(t, dir, "Product", "name:string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(dir, "internal/models/product.go")
+	writeFile(t, existing, "package models\n")
+
+	if err := p.Apply(); err == nil {
+		t.Fatal("Apply() succeeded despite an existing file")
+	}
+	if content := readFile(t, existing); content != "package models\n" {
+		t.Errorf("existing file was overwritten: %q", content)
+	}
+}
+
+func TestPlanFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, dir string)
+		want    string
+	}{
+		{
+			name: "missing marker",
+			prepare: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "internal/services/services.go")
+				content := strings.Replace(readFile(t, path), "// Add more services here", "", 1)
+				writeFile(t, path, content)
+			},
+			want: `internal/services/services.go: expected 2 "// Add more services here" marker(s), found 1`,
+		},
+		{
+			name: "already wired",
+			prepare: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "internal/routes/routes.go")
+				content := strings.Replace(readFile(t, path), "// TODO: Add more API routes here",
+					"controllers.Product.Mount(nil)\n\t// TODO: Add more API routes here", 1)
+				writeFile(t, path, content)
+			},
+			want: "internal/routes/routes.go already registers Product",
+		},
+		{
+			name: "migration exists",
+			prepare: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "internal/database/migrations/000002_create_products.up.sql"), "")
+			},
+			want: "migration 000002_create_products.up.sql already creates products",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestModule(t)
+			tt.prepare(t, dir)
+
+			_, err := planResource(t, dir, "Product", "name:string")
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Plan() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGeneratorRequiresModule(t *testing.T) {
+	r, err := parseResource("Product", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newGenerator(t.TempDir(), r); err == nil {
+		t.Error("newGenerator() succeeded without a go.mod")
+	}
+}
+
+// TestScaffoldedModuleBuilds scaffolds resources into a copy of this module
+// and checks that it builds and that the generated tests pass
+func TestScaffoldedModuleBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a copy of the module")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir := t.TempDir()
+	copyModule(t, filepath.Join("..", ".."), dir)
+
+	resources := []struct {
+		name   string
+		fields []string
+	}{
+		{"OrderItem", []string{"name:string:required:unique", "price:decimal", "due:time", "notes:text", "quantity:int"}},
+		// A resource without fields must build too
+		{"Tag", nil},
+	}
+	for _, r := range resources {
+		p, err := planResource(t, dir, r.name, r.fields...)
+		if err != nil {
+			t.Fatalf("Plan(%s) error = %v", r.name, err)
+		}
+		if err := p.Apply(); err != nil {
+			t.Fatalf("Apply(%s) error = %v", r.name, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"build", "./..."},
+		{"vet", "./..."},
+		{"test", "./internal/controllers/..."},
+	} {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+}
+
+// copyModule copies the module's source tree, without build output, to dst
+func copyModule(t *testing.T, src, dst string) {
+	t.Helper()
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel == "bin" || rel == "tmp" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dst, rel), 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), content, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
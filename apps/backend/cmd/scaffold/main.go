@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRootCmd builds the scaffold command tree
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "scaffold",
+		Short:        "Generate code for new resources",
+		SilenceUsage: true,
+	}
+
+	root.AddCommand(newResourceCmd())
+
+	return root
+}
+
+// newResourceCmd creates the command that generates a CRUD resource
+func newResourceCmd() *cobra.Command {
+	var dir string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "resource <Name> [field:type[:modifier...]]...",
+		Short: "Generate a model, migration, repository, service, controller, routes and tests",
+		Long: `Generate every layer of a CRUD resource and wire it into the application.
+
+Field types: ` + strings.Join(fieldTypeNames(), ", ") + `
+Field modifiers: required, unique
+
+Example:
+  go run ./cmd/scaffold resource Product name:string:required price:decimal
+
+Existing files are never overwritten; the command fails before writing anything
+if one of the files it would create already exists.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resource, err := parseResource(args[0], args[1:])
+			if err != nil {
+				return err
+			}
+
+			generator, err := newGenerator(dir, resource)
+			if err != nil {
+				return err
+			}
+
+			plan, err := generator.Plan()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for _, file := range plan.Files {
+				fmt.Fprintf(out, "create  %s\n", file.Path)
+			}
+			for _, edit := range plan.Edits {
+				fmt.Fprintf(out, "update  %s\n", edit.Path)
+			}
+			if dryRun {
+				return nil
+			}
+
+			return plan.Apply()
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "backend module directory (the one containing go.mod)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be created or updated without writing them")
+
+	return cmd
+}
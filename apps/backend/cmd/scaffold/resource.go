@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fieldType describes how a scaffold field type maps onto Go, SQL and the query layer
+type fieldType struct {
+	GoType    string
+	SQLType   string
+	GormType  string
+	Filter    string
+	Validate  string
+	Sample    string // JSON value used by the generated tests
+	Sortable  bool
+	Searched  bool
+	NeedsTime bool
+}
+
+// fieldTypes are the field types understood by the generator. Decimals are
+// stored exactly as NUMERIC; use a decimal library if you need exact arithmetic in Go.
+var fieldTypes = map[string]fieldType{
+	"string":  {GoType: "string", SQLType: "VARCHAR(255)", GormType: "size:255", Filter: "query.String", Validate: "max=255", Sample: `"example"`, Sortable: true, Searched: true},
+	"text":    {GoType: "string", SQLType: "TEXT", GormType: "type:text", Sample: `"example"`, Searched: true},
+	"int":     {GoType: "int", SQLType: "INTEGER", Filter: "query.Int", Sample: "1", Sortable: true},
+	"int64":   {GoType: "int64", SQLType: "BIGINT", Filter: "query.Int", Sample: "1", Sortable: true},
+	"bool":    {GoType: "bool", SQLType: "BOOLEAN", Filter: "query.Bool", Sample: "true", Sortable: true},
+	"float":   {GoType: "float64", SQLType: "DOUBLE PRECISION", Sample: "1.5", Sortable: true},
+	"decimal": {GoType: "float64", SQLType: "NUMERIC(12,2)", GormType: "type:numeric(12,2)", Sample: "9.99", Sortable: true},
+	"time":    {GoType: "time.Time", SQLType: "TIMESTAMPTZ", Sample: `"2024-01-01T00:00:00Z"`, Sortable: true, NeedsTime: true},
+}
+
+// Patterns for resource and field names
+var (
+	resourceNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	fieldNamePattern    = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// reservedFields are provided by models.BaseModel
+var reservedFields = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "version": true,
+}
+
+// initialisms are rendered in upper case in Go identifiers
+var initialisms = map[string]bool{
+	"id": true, "url": true, "api": true, "http": true, "ip": true, "uuid": true, "sku": true,
+}
+
+// resource is a parsed scaffold request
+type resource struct {
+	Name        string // Product
+	Plural      string // Products
+	Var         string // product
+	Snake       string // product
+	Table       string // products
+	Path        string // /products
+	Label       string // product, used in error codes and messages
+	PluralLabel string // products
+	Fields      []field
+	ModulePath  string
+}
+
+// permission is a permission generated for a resource
+type permission struct {
+	Field       string // Create, the models.ResourcePermissions field it sets
+	Const       string // PermissionProductsCreate
+	Name        string // products:create
+	Description string // Create products
+}
+
+// field is a parsed field:type[:modifier...] argument
+type field struct {
+	Name     string // unit_price
+	GoName   string // UnitPrice
+	Type     fieldType
+	Required bool
+	Unique   bool
+}
+
+// parseResource validates the resource name and field arguments
+func parseResource(name string, args []string) (*resource, error) {
+	if !resourceNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("resource name %q must be a CamelCase Go identifier such as Product", name)
+	}
+
+	snake := toSnake(name)
+	r := &resource{
+		Name:  name,
+		Var:   strings.ToLower(name[:1]) + name[1:],
+		Snake: snake,
+		Table: pluralize(snake),
+		Label: strings.ReplaceAll(snake, "_", " "),
+	}
+	r.Plural = toCamel(r.Table)
+	r.Path = "/" + strings.ReplaceAll(r.Table, "_", "-")
+	r.PluralLabel = strings.ReplaceAll(r.Table, "_", " ")
+
+	seen := map[string]bool{}
+	for _, arg := range args {
+		f, err := parseField(arg)
+		if err != nil {
+			return nil, err
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("field %q is defined twice", f.Name)
+		}
+		seen[f.Name] = true
+		r.Fields = append(r.Fields, f)
+	}
+
+	return r, nil
+}
+
+// parseField parses a single field:type[:modifier...] argument
+func parseField(arg string) (field, error) {
+	parts := strings.Split(arg, ":")
+	if len(parts) < 2 {
+		return field{}, fmt.Errorf("field %q must be written as name:type", arg)
+	}
+
+	name, typeName := parts[0], parts[1]
+	if !fieldNamePattern.MatchString(name) {
+		return field{}, fmt.Errorf("field name %q must be snake_case", name)
+	}
+	if reservedFields[name] {
+		return field{}, fmt.Errorf("field %q is provided by models.BaseModel", name)
+	}
+
+	ft, ok := fieldTypes[typeName]
+	if !ok {
+		return field{}, fmt.Errorf("unknown type %q for field %q, expected one of %s",
+			typeName, name, strings.Join(fieldTypeNames(), ", "))
+	}
+
+	f := field{Name: name, GoName: toCamel(name), Type: ft}
+	for _, modifier := range parts[2:] {
+		switch modifier {
+		case "required":
+			f.Required = true
+		case "unique":
+			f.Unique = true
+		default:
+			return field{}, fmt.Errorf("unknown modifier %q for field %q, expected required or unique", modifier, name)
+		}
+	}
+	return f, nil
+}
+
+// GormTag renders the gorm struct tag of the field. Unique indexes ignore
+// soft-deleted rows, matching the migration.
+func (r *resource) GormTag(f field) string {
+	var parts []string
+	if f.Type.GormType != "" {
+		parts = append(parts, f.Type.GormType)
+	}
+	if f.Required {
+		parts = append(parts, "not null")
+	}
+	if f.Unique {
+		parts = append(parts, "uniqueIndex:idx_"+r.Table+"_"+f.Name+",where:deleted_at IS NULL")
+	}
+	return strings.Join(parts, ";")
+}
+
+// ValidateTag renders the validate struct tag of the field
+func (f field) ValidateTag() string {
+	var parts []string
+	if f.Required {
+		parts = append(parts, "required")
+	}
+	if f.Type.Validate != "" {
+		parts = append(parts, f.Type.Validate)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Columns renders the migration's column definitions for the fields,
+// aligned with the base columns
+func (r *resource) Columns() []string {
+	width := r.columnWidth()
+	columns := make([]string, 0, len(r.Fields))
+	for _, f := range r.Fields {
+		column := fmt.Sprintf("%-*s %s", width, f.Name, f.Type.SQLType)
+		if f.Required {
+			column += " NOT NULL"
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// Pad right-pads a base column name to the width of the field columns
+func (r *resource) Pad(name string) string {
+	return fmt.Sprintf("%-*s", r.columnWidth(), name)
+}
+
+// columnWidth is the length of the longest column name
+func (r *resource) columnWidth() int {
+	width := len("created_at")
+	for _, f := range r.Fields {
+		width = max(width, len(f.Name))
+	}
+	return width
+}
+
+// NeedsTime reports whether the model needs to import time
+func (r *resource) NeedsTime() bool {
+	for _, f := range r.Fields {
+		if f.Type.NeedsTime {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions lists the create, read, update and delete permissions of the
+// resource, named "<table>:<action>" like the built-in users permissions
+func (r *resource) Permissions() []permission {
+	actions := []struct{ field, verb string }{
+		{"Create", "Create"},
+		{"Read", "View"},
+		{"Update", "Update"},
+		{"Delete", "Delete"},
+	}
+
+	permissions := make([]permission, 0, len(actions))
+	for _, action := range actions {
+		permissions = append(permissions, permission{
+			Field:       action.field,
+			Const:       "Permission" + r.Plural + action.field,
+			Name:        r.Table + ":" + strings.ToLower(action.field),
+			Description: action.verb + " " + r.PluralLabel,
+		})
+	}
+	return permissions
+}
+
+// Title is the capitalized label used in response messages, e.g. "Order item"
+func (r *resource) Title() string {
+	return strings.ToUpper(r.Label[:1]) + r.Label[1:]
+}
+
+// SearchExpression is the SQL expression matched by ?q=, concatenating the
+// text fields. It is empty, disabling search, when there are none.
+func (r *resource) SearchExpression() string {
+	var columns []string
+	for _, f := range r.Fields {
+		if f.Type.Searched {
+			columns = append(columns, "COALESCE("+f.Name+", '')")
+		}
+	}
+	if len(columns) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(columns, " || ' ' || ") + ")"
+}
+
+// RequiredField returns the first required field, used by the generated tests, if any
+func (r *resource) RequiredField() *field {
+	for i, f := range r.Fields {
+		if f.Required {
+			return &r.Fields[i]
+		}
+	}
+	return nil
+}
+
+// SampleJSON renders a valid request body for the generated tests
+func (r *resource) SampleJSON() string {
+	return "{" + strings.Join(r.sampleValues(), ", ") + "}"
+}
+
+// SampleJSONWithBase renders a valid request body that also tries to set
+// base fields, which the generated tests check are ignored
+func (r *resource) SampleJSONWithBase() string {
+	values := append([]string{`"id": 42`, `"version": 7`}, r.sampleValues()...)
+	return "{" + strings.Join(values, ", ") + "}"
+}
+
+// sampleValues renders a "name": value pair for each field
+func (r *resource) sampleValues() []string {
+	values := make([]string, 0, len(r.Fields))
+	for _, f := range r.Fields {
+		values = append(values, fmt.Sprintf("%q: %s", f.Name, f.Type.Sample))
+	}
+	return values
+}
+
+// fieldTypeNames lists the supported field types
+func fieldTypeNames() []string {
+	names := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// toSnake converts CamelCase to snake_case
+func toSnake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toCamel converts snake_case to CamelCase, upper-casing common initialisms
+func toCamel(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		if initialisms[part] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// pluralize returns the English plural of a snake_case noun, for table names
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
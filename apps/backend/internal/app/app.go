@@ -1,20 +1,33 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/albuquerquewizard/monorepo/backend/internal/config"
 	"github.com/albuquerquewizard/monorepo/backend/internal/controllers"
+	"github.com/albuquerquewizard/monorepo/backend/internal/database"
+	"github.com/albuquerquewizard/monorepo/backend/internal/health"
+	"github.com/albuquerquewizard/monorepo/backend/internal/jobs"
+	"github.com/albuquerquewizard/monorepo/backend/internal/lifecycle"
+	"github.com/albuquerquewizard/monorepo/backend/internal/metrics"
 	"github.com/albuquerquewizard/monorepo/backend/internal/middleware"
 	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/ratelimit"
 	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
 	"github.com/albuquerquewizard/monorepo/backend/internal/routes"
 	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/albuquerquewizard/monorepo/backend/internal/tracing"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/timeout"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 )
 
@@ -26,31 +39,82 @@ type App struct {
 	Repos       *repositories.Repositories
 	Services    *services.Services
 	Controllers *controllers.Controllers
+	Purger      *jobs.Purger
+	Health      *health.Registry
+	// Lifecycle runs the shutdown hooks; subsystems register theirs with OnShutdown
+	Lifecycle *lifecycle.Manager
+	// Metrics is nil when metrics are disabled
+	Metrics *metrics.Metrics
+
+	// metricsServer serves metrics when they have their own listener
+	metricsServer *http.Server
+	// shutdownTracing flushes spans that have not been exported yet
+	shutdownTracing tracing.ShutdownFunc
+	// redis is nil unless rate limits are kept in Redis
+	redis  *redis.Client
+	logger zerolog.Logger
 }
 
-// NewApp creates a new application instance
+// cleanupTimeout bounds the shutdown steps that follow draining requests
+const cleanupTimeout = 10 * time.Second
+
+// insecureDevelopmentJWTSecret signs tokens in development when JWT_SECRET is unset
+const insecureDevelopmentJWTSecret = "insecure-development-jwt-secret"
+
+// NewApp creates a new application instance, connecting to, migrating and seeding the database
 func NewApp(cfg *config.Config, logger zerolog.Logger) *App {
 	// Initialize database
-	database := config.NewDatabase(cfg)
+	database := config.NewDatabase(cfg, logger)
 
 	// Run database migrations
-	if err := models.AutoMigrate(database.DB); err != nil {
+	if err := migrateDatabase(cfg, database, logger); err != nil {
 		logger.Fatal().Err(err).Msg("Failed to run database migrations")
 	}
 
 	// Seed initial data
-	if err := models.SeedData(database.DB); err != nil {
+	if err := models.SeedData(database.DB, logger); err != nil {
 		logger.Warn().Err(err).Msg("Failed to seed initial data")
 	}
 
+	return New(cfg, logger, database)
+}
+
+// New wires repositories, services, controllers and routes on top of an
+// existing database. Nothing is queried while wiring, so tooling that only
+// needs the route table can pass a Database that is not connected.
+func New(cfg *config.Config, logger zerolog.Logger, database *config.Database) *App {
+	// Tokens must never be signed with a guessable secret outside development
+	if cfg.JWT.Secret == "" {
+		if cfg.App.Env != "development" {
+			logger.Fatal().Msg("JWT_SECRET must be set")
+		}
+		logger.Warn().Msg("JWT_SECRET is not set, using an insecure development secret")
+		cfg.JWT.Secret = insecureDevelopmentJWTSecret
+	}
+	if cfg.Pagination.CursorSecret == "" {
+		cfg.Pagination.CursorSecret = cfg.JWT.Secret
+	}
+
+	// Tracing is set up first so that every component records into the configured provider
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+	// The database is absent when the app is only wired to inspect routes
+	if database.DB != nil {
+		if err := database.DB.Use(tracing.NewPlugin(cfg.Database.Name)); err != nil {
+			logger.Warn().Err(err).Msg("Failed to instrument database tracing")
+		}
+	}
+
 	// Initialize repositories
 	repos := repositories.NewRepositories(database.DB)
 
 	// Initialize services
-	svcs := services.NewServices(repos)
+	svcs := services.NewServices(repos, cfg, middleware.Validate)
 
 	// Initialize controllers
-	ctrls := controllers.NewControllers(svcs)
+	ctrls := controllers.NewControllers(svcs, pagination.NewCodec(cfg.Pagination.CursorSecret))
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -58,20 +122,47 @@ func NewApp(cfg *config.Config, logger zerolog.Logger) *App {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
+		// Client IPs, which rate limits and logs rely on, are only taken from
+		// the proxy header on requests from trusted proxies
+		ProxyHeader:             cfg.Proxy.Header,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Proxy.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
+	// Metrics are recorded by the outermost middleware so they cover every request
+	appMetrics, metricsServer := setupMetrics(app, cfg, logger, database)
+
 	// Setup middleware
 	setupMiddleware(app, cfg, logger)
 
 	// Setup custom error handlers
 	app.Use(func(c *fiber.Ctx) error {
-		// Add timestamp to context for error handlers
+		// Add timestamp and environment to context for error handlers
 		c.Locals("timestamp", time.Now().UTC().Format(time.RFC3339))
+		c.Locals("env", cfg.App.Env)
 		return c.Next()
 	})
 
+	// Setup health probes; /health and /api/health are kept for existing
+	// monitors and report readiness. They are registered before the API
+	// routes so that probes are not rate limited.
+	healthRegistry := setupHealth(cfg, logger, database)
+	app.Hooks().OnListen(func(fiber.ListenData) error {
+		healthRegistry.MarkStarted()
+		return nil
+	})
+	app.Get("/livez", healthRegistry.Livez)
+	app.Get("/readyz", healthRegistry.Readyz)
+	app.Get("/startupz", healthRegistry.Startupz)
+	app.Get("/health", healthRegistry.Readyz)
+	app.Get("/api/health", healthRegistry.Readyz)
+
+	// Setup rate limiting
+	limiter, redisClient := setupRateLimit(cfg, logger, healthRegistry)
+
 	// Setup routes
-	routes.SetupRoutes(app, ctrls)
+	routes.SetupRoutes(app, ctrls, svcs, limiter)
 
 	// Setup 404 handler
 	app.Use(middleware.NotFoundHandler)
@@ -79,35 +170,131 @@ func NewApp(cfg *config.Config, logger zerolog.Logger) *App {
 	// Setup 405 handler
 	app.Use(middleware.MethodNotAllowedHandler)
 
-	// Setup health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		// Check database health
-		if err := database.HealthCheck(); err != nil {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status": "unhealthy",
-				"error":  "Database connection failed",
-			})
-		}
-
-		return c.JSON(fiber.Map{
-			"status":    "healthy",
-			"timestamp": time.Now().UTC(),
-			"version":   "1.0.0",
-		})
-	})
-
-	return &App{
+	a := &App{
 		FiberApp:    app,
 		Config:      cfg,
 		Database:    database,
 		Repos:       repos,
 		Services:    svcs,
 		Controllers: ctrls,
+		Purger:      jobs.NewPurger(svcs.User, cfg.Purge, logger),
+		Health:      healthRegistry,
+		Lifecycle:   lifecycle.NewManager(logger),
+		Metrics:     appMetrics,
+
+		metricsServer:   metricsServer,
+		shutdownTracing: shutdownTracing,
+		redis:           redisClient,
+		logger:          logger,
+	}
+	a.registerShutdownHooks()
+
+	return a
+}
+
+// setupMetrics instruments the app and database and exposes the metrics,
+// either on the API port or on their own listener. It returns the server
+// for the separate listener, if one is configured.
+func setupMetrics(app *fiber.App, cfg *config.Config, logger zerolog.Logger, database *config.Database) (*metrics.Metrics, *http.Server) {
+	if !cfg.Metrics.Enabled {
+		return nil, nil
+	}
+
+	appMetrics := metrics.New()
+	app.Use(appMetrics.Middleware())
+
+	// The database is absent when the app is only wired to inspect routes
+	if database.DB != nil {
+		if err := appMetrics.InstrumentDB(database.DB, cfg.Database.Name); err != nil {
+			logger.Warn().Err(err).Msg("Failed to instrument database metrics")
+		}
+	}
+
+	handler := appMetrics.Handler(cfg.Metrics.Token)
+	if cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(cfg.Metrics.Path, handler)
+		return appMetrics, &http.Server{
+			Addr:              cfg.Metrics.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+
+	if cfg.Metrics.Token == "" && cfg.App.Env != "development" {
+		logger.Warn().Msg("Metrics are served on the API port without METRICS_TOKEN; set METRICS_ADDR or METRICS_TOKEN")
+	}
+	app.Get(cfg.Metrics.Path, adaptor.HTTPHandler(handler))
+	return appMetrics, nil
+}
+
+// setupHealth registers the dependency checks run by the readiness and
+// startup probes. Caches and queues register their own checks here as they
+// are added.
+func setupHealth(cfg *config.Config, logger zerolog.Logger, db *config.Database) *health.Registry {
+	registry := health.NewRegistry(cfg.Health.CheckTimeout)
+
+	// The database is absent when the app is only wired to inspect routes
+	if db.DB == nil {
+		return registry
 	}
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to register database health checks")
+		return registry
+	}
+	registry.Register("database", health.Database(sqlDB))
+
+	if cfg.Database.MigrationMode != config.MigrationModeOff {
+		migrator, err := database.NewMigrator(sqlDB)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to register migrations health check")
+			return registry
+		}
+		registry.Register("migrations", health.Migrations(migrator))
+	}
+
+	return registry
+}
+
+// setupRateLimit creates the rate limiter with its configured store. A Redis
+// store shares limits between instances and is checked by the readiness
+// probe; the client is returned so that it can be closed on shutdown.
+func setupRateLimit(cfg *config.Config, logger zerolog.Logger, registry *health.Registry) (*ratelimit.Limiter, *redis.Client) {
+	switch cfg.RateLimit.Store {
+	case config.RateLimitStoreRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr(),
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		registry.Register("redis", health.CheckerFunc(func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		}))
+		return ratelimit.NewLimiter(cfg.RateLimit, ratelimit.NewRedisStore(client), logger), client
+
+	case config.RateLimitStoreMemory:
+	default:
+		logger.Warn().Msgf("Unknown RATE_LIMIT_STORE %q, using memory", cfg.RateLimit.Store)
+	}
+	return ratelimit.NewLimiter(cfg.RateLimit, ratelimit.NewMemoryStore(), logger), nil
 }
 
 // setupMiddleware configures all middleware for the application
 func setupMiddleware(app *fiber.App, cfg *config.Config, appLogger zerolog.Logger) {
+	// Tracing middleware continues incoming traces and spans the whole request
+	app.Use(tracing.Middleware())
+
+	// Request ID middleware identifies the request in responses and logs
+	app.Use(middleware.RequestID(appLogger))
+
+	// Access log middleware writes one structured line per request
+	app.Use(middleware.AccessLog(appLogger))
+
+	// Error responder middleware turns the errors handlers return into
+	// responses, inside the middleware that observe the sent status
+	app.Use(middleware.ErrorResponder())
+
 	// Custom panic recovery middleware with logging
 	app.Use(middleware.PanicRecoveryMiddleware(appLogger))
 
@@ -116,31 +303,90 @@ func setupMiddleware(app *fiber.App, cfg *config.Config, appLogger zerolog.Logge
 		AllowOrigins:     strings.Join(cfg.CORS.AllowedOrigins, ","),
 		AllowMethods:     strings.Join(cfg.CORS.AllowedMethods, ","),
 		AllowHeaders:     strings.Join(cfg.CORS.AllowedHeaders, ","),
+		ExposeHeaders:    strings.Join(cfg.CORS.ExposedHeaders, ","),
 		AllowCredentials: true,
 	}))
 
-	// Logger middleware
-	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${latency} ${method} ${path}\n",
-	}))
-
-	// Timeout middleware for all routes
-	app.Use(timeout.New(func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusRequestTimeout).JSON(fiber.Map{
-			"error": "Request timeout",
-		})
+	// Timeout middleware for all routes. It sets a deadline on the user
+	// context, which cancels the request's queries, and responds with 408
+	// when a handler fails because the deadline passed.
+	app.Use(timeout.NewWithContext(func(c *fiber.Ctx) error {
+		return c.Next()
 	}, 30*time.Second))
 }
 
-// Start starts the application
+// Start starts the background jobs, the metrics listener and the HTTP server
 func (a *App) Start() error {
+	a.Purger.Start()
+
+	if a.metricsServer != nil {
+		go func() {
+			a.logger.Info().Msgf("📈 Metrics available at http://localhost%s%s", a.metricsServer.Addr, a.Config.Metrics.Path)
+			if err := a.metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.logger.Error().Err(err).Msg("Metrics server failed")
+			}
+		}()
+	}
+
 	return a.FiberApp.Listen(":" + a.Config.App.Port)
 }
 
-// Shutdown gracefully shuts down the application
-func (a *App) Shutdown() error {
-	if err := a.Database.Close(); err != nil {
-		return err
+// registerShutdownHooks registers the shutdown steps of the app's own
+// subsystems. Nothing is closed before the requests that may use it have
+// drained, and telemetry is flushed once the work it records has stopped.
+func (a *App) registerShutdownHooks() {
+	a.Lifecycle.OnShutdown(lifecycle.PhaseNotReady, "readiness", func(ctx context.Context) error {
+		a.Health.MarkShuttingDown()
+		if a.Config.Shutdown.Delay <= 0 {
+			return nil
+		}
+		a.logger.Info().Msgf("⏳ Waiting %s for load balancers to stop routing requests", a.Config.Shutdown.Delay)
+		select {
+		case <-time.After(a.Config.Shutdown.Delay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	a.Lifecycle.OnShutdown(lifecycle.PhaseListeners, "http server", func(ctx context.Context) error {
+		// Stops accepting connections, then closes the remaining ones once the deadline passes
+		if err := a.FiberApp.ShutdownWithTimeout(a.Config.Shutdown.Timeout); err != nil {
+			return fmt.Errorf("in-flight requests did not finish within %s: %w", a.Config.Shutdown.Timeout, err)
+		}
+		return nil
+	})
+
+	a.Lifecycle.OnShutdown(lifecycle.PhaseWorkers, "purger", func(ctx context.Context) error {
+		a.Purger.Stop()
+		return nil
+	})
+
+	a.Lifecycle.OnShutdown(lifecycle.PhaseTelemetry, "tracing", lifecycle.Hook(a.shutdownTracing))
+	if a.metricsServer != nil {
+		a.Lifecycle.OnShutdown(lifecycle.PhaseTelemetry, "metrics server", a.metricsServer.Shutdown)
+	}
+
+	// The database is absent when the app is only wired to inspect routes
+	if a.Database.DB != nil {
+		a.Lifecycle.OnShutdown(lifecycle.PhaseResources, "database", func(ctx context.Context) error {
+			return a.Database.Close()
+		})
+	}
+	if a.redis != nil {
+		a.Lifecycle.OnShutdown(lifecycle.PhaseResources, "redis", func(ctx context.Context) error {
+			return a.redis.Close()
+		})
 	}
-	return a.FiberApp.Shutdown()
+}
+
+// Shutdown gracefully shuts down the application: readiness fails, the
+// server stops accepting connections and drains in-flight requests, then
+// background jobs stop, telemetry is flushed and the database is closed
+func (a *App) Shutdown() error {
+	timeout := a.Config.Shutdown.Delay + a.Config.Shutdown.Timeout + cleanupTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return a.Lifecycle.Shutdown(ctx)
 }
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/database"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/rs/zerolog"
+)
+
+// migrationTimeout bounds how long startup waits on the migration lock and migrations
+const migrationTimeout = 5 * time.Minute
+
+// migrateDatabase brings the schema up to date according to the configured
+// migration mode, then runs GORM AutoMigrate when enabled in development
+func migrateDatabase(cfg *config.Config, db *config.Database, logger zerolog.Logger) error {
+	if err := runVersionedMigrations(cfg.Database.MigrationMode, db, logger); err != nil {
+		return err
+	}
+
+	if cfg.Database.AutoMigrate {
+		if cfg.App.Env != "development" {
+			logger.Warn().Msg("DB_AUTO_MIGRATE is ignored outside development")
+			return nil
+		}
+		return models.AutoMigrate(db.DB, logger)
+	}
+
+	return nil
+}
+
+// runVersionedMigrations applies or checks the embedded SQL migrations
+func runVersionedMigrations(mode string, db *config.Database, logger zerolog.Logger) error {
+	if mode == config.MigrationModeOff {
+		logger.Warn().Msg("Versioned migrations are disabled")
+		return nil
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	migrator, err := database.NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	switch mode {
+	case config.MigrationModeApply:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			logger.Info().Int64("version", m.Version).Str("name", m.Name).Msg("Applied migration")
+		}
+		logger.Info().Int("applied", len(applied)).Msg("Database schema is up to date")
+
+	case config.MigrationModeCheck:
+		// Migrations applied by a newer build are fine, e.g. during a rolling deployment
+		pending, err := migrator.Unapplied(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("database schema is behind: %d pending migrations, starting with %d_%s",
+				len(pending), pending[0].Version, pending[0].Name)
+		}
+		logger.Info().Msg("Database schema is up to date")
+
+	default:
+		return fmt.Errorf("unknown DB_MIGRATION_MODE %q", mode)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package apperrors
+
+import (
+	"errors"
+	"time"
+)
+
+// Kind classifies a domain error so that transports can map it to a response
+type Kind int
+
+// Error kinds
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindRateLimited
+	KindTimeout
+	KindPreconditionFailed
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindBadRequest:
+		return "bad_request"
+	case KindValidation:
+		return "validation_failed"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindRateLimited:
+		return "rate_limited"
+	case KindTimeout:
+		return "timeout"
+	case KindPreconditionFailed:
+		return "precondition_failed"
+	case KindInternal:
+		return "internal_error"
+	default:
+		return "internal_error"
+	}
+}
+
+// Generic sentinel errors, one per kind. errors.Is(err, ErrNotFound) matches
+// every not-found error regardless of its code.
+var (
+	ErrInternal     = &Error{Kind: KindInternal}
+	ErrBadRequest   = &Error{Kind: KindBadRequest}
+	ErrValidation   = &Error{Kind: KindValidation}
+	ErrUnauthorized = &Error{Kind: KindUnauthorized}
+	ErrForbidden    = &Error{Kind: KindForbidden}
+	ErrNotFound     = &Error{Kind: KindNotFound}
+	ErrConflict     = &Error{Kind: KindConflict}
+	ErrRateLimited  = &Error{Kind: KindRateLimited}
+	ErrTimeout      = &Error{Kind: KindTimeout}
+
+	ErrPreconditionFailed = &Error{Kind: KindPreconditionFailed}
+)
+
+// Error is a domain error. Code is a stable, machine-readable identifier and
+// Message is safe to show to clients; the wrapped Err is for logs only.
+type Error struct {
+	Kind       Kind
+	Code       string
+	Message    string
+	Fields     map[string]string
+	RetryAfter time.Duration
+	Err        error
+}
+
+// New creates a domain error of the given kind
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// BadRequest creates an error for malformed requests
+func BadRequest(code, message string) *Error {
+	return New(KindBadRequest, code, message)
+}
+
+// Validation creates an error for invalid input, with per-field messages
+func Validation(message string, fields map[string]string) *Error {
+	err := New(KindValidation, KindValidation.String(), message)
+	err.Fields = fields
+	return err
+}
+
+// Unauthorized creates an error for missing or invalid credentials
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Forbidden creates an error for authenticated callers lacking permission
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// NotFound creates an error for missing resources
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict creates an error for requests that clash with the current state
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// PreconditionFailed creates an error for conditional requests whose
+// precondition, such as an If-Match version, no longer holds
+func PreconditionFailed(code, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+// RateLimited creates an error for callers that exceeded a rate limit
+func RateLimited(retryAfter time.Duration) *Error {
+	err := New(KindRateLimited, KindRateLimited.String(), "Too many requests. Please try again later.")
+	err.RetryAfter = retryAfter
+	return err
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.String()
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a domain error of the same kind and, if the
+// target has a code, the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Kind == e.Kind && (t.Code == "" || t.Code == e.Code)
+}
+
+// Wrap returns a copy of the error with err attached as its cause
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// As returns the domain error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of the domain error in err's chain, or KindInternal
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return KindInternal
+}
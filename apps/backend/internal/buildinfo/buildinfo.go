@@ -0,0 +1,59 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Build metadata, set at build time with
+//
+//	go build -ldflags "-X github.com/albuquerquewizard/monorepo/backend/internal/buildinfo.Version=v1.2.3
+//	  -X github.com/albuquerquewizard/monorepo/backend/internal/buildinfo.Commit=$(git rev-parse HEAD)
+//	  -X github.com/albuquerquewizard/monorepo/backend/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+var (
+	info     Info
+	infoOnce sync.Once
+)
+
+// Get returns the build metadata. When the commit was not set with ldflags,
+// it is read from the VCS stamp Go embeds in binaries built inside a
+// repository. Values that are still missing are "unknown".
+func Get() Info {
+	infoOnce.Do(func() {
+		info = Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+
+		if build, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range build.Settings {
+				if setting.Key == "vcs.revision" && info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			}
+		}
+
+		if info.Commit == "" {
+			info.Commit = "unknown"
+		}
+		if info.BuildTime == "" {
+			info.BuildTime = "unknown"
+		}
+	})
+	return info
+}
+
+// String formats the build metadata for humans, e.g. "v1.2.3 (commit abc123, built 2025-01-02T03:04:05Z)"
+func (i Info) String() string {
+	return i.Version + " (commit " + i.Commit + ", built " + i.BuildTime + ")"
+}
@@ -1,19 +1,33 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
-	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/albuquerquewizard/monorepo/backend/internal/logging"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	CORS     CORSConfig
-	Log      LogConfig
+	App        AppConfig
+	Proxy      ProxyConfig
+	Database   DatabaseConfig
+	CORS       CORSConfig
+	Log        LogConfig
+	JWT        JWTConfig
+	Pagination PaginationConfig
+	Purge      PurgeConfig
+	Metrics    MetricsConfig
+	Tracing    TracingConfig
+	Health     HealthConfig
+	Shutdown   ShutdownConfig
+	Redis      RedisConfig
+	RateLimit  RateLimitConfig
 }
 
 type AppConfig struct {
@@ -22,6 +36,15 @@ type AppConfig struct {
 	Name string
 }
 
+type ProxyConfig struct {
+	// Header holds the client IP set by a reverse proxy, such as X-Real-IP.
+	// It is only read on requests from TrustedProxies; when empty, clients are
+	// identified by the connection's address.
+	Header string
+	// TrustedProxies are the IP addresses and CIDR ranges of the proxies
+	TrustedProxies []string
+}
+
 type DatabaseConfig struct {
 	Host     string
 	Port     string
@@ -30,17 +53,176 @@ type DatabaseConfig struct {
 	Name     string
 	SSLMode  string
 	URL      string
+	// MigrationMode controls versioned migrations at startup: "apply" runs
+	// pending migrations, "check" refuses to start if any are pending, "off" skips them
+	MigrationMode string
+	// AutoMigrate additionally runs GORM AutoMigrate; only honoured in development
+	AutoMigrate bool
 }
 
+// Migration modes for DatabaseConfig.MigrationMode
+const (
+	MigrationModeApply = "apply"
+	MigrationModeCheck = "check"
+	MigrationModeOff   = "off"
+)
+
 type CORSConfig struct {
 	AllowedOrigins []string
 	AllowedMethods []string
 	AllowedHeaders []string
+	ExposedHeaders []string
 }
 
 type LogConfig struct {
-	Level  string
+	Level string
+	// Format is "json" or "console"; it defaults to console in development and JSON elsewhere
 	Format string
+	// SlowQueryThreshold is the duration above which queries are logged as
+	// warnings; 0 disables slow query warnings
+	SlowQueryThreshold time.Duration
+	// RedactFields are redacted from logged bodies, headers and errors in
+	// addition to the built-in denylist of passwords, tokens and credentials
+	RedactFields []string
+}
+
+type JWTConfig struct {
+	Secret          string
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type PaginationConfig struct {
+	// CursorSecret signs pagination cursors; the JWT secret is used when empty
+	CursorSecret string
+}
+
+type PurgeConfig struct {
+	// AfterDays is how many days soft-deleted records are kept before the
+	// purge job deletes them permanently; 0 disables the job
+	AfterDays int
+	// Interval is how often the purge job runs
+	Interval time.Duration
+}
+
+// Retention returns how long soft-deleted records are kept
+func (c PurgeConfig) Retention() time.Duration {
+	return time.Duration(c.AfterDays) * 24 * time.Hour
+}
+
+type MetricsConfig struct {
+	Enabled bool
+	// Path is where metrics are served in Prometheus text format
+	Path string
+	// Addr serves metrics on a separate listener, e.g. ":9090", instead of the API port
+	Addr string
+	// Token, when set, must be presented as a bearer token to read metrics
+	Token string
+}
+
+type TracingConfig struct {
+	// Exporter is where spans are sent: "otlp", "stdout" or "none". The OTLP
+	// exporter is configured with the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter string
+	// File makes the stdout exporter write to a file instead of standard output
+	File string
+	// SampleRatio is the fraction of new traces that are recorded. Requests
+	// continuing a trace follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// Exporters for TracingConfig.Exporter
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+type HealthConfig struct {
+	// CheckTimeout bounds each dependency check run by the health probes
+	CheckTimeout time.Duration
+}
+
+type ShutdownConfig struct {
+	// Delay is how long the server keeps serving after readiness starts
+	// failing, so load balancers notice before connections are refused
+	Delay time.Duration
+	// Timeout bounds how long in-flight requests are given to finish before
+	// their connections are closed
+	Timeout time.Duration
+}
+
+type RedisConfig struct {
+	Host     string
+	Port     string
+	Password string
+	DB       int
+}
+
+// Addr returns the Redis address in host:port form
+func (c RedisConfig) Addr() string {
+	return c.Host + ":" + c.Port
+}
+
+type RateLimitConfig struct {
+	Enabled bool
+	// Store holds the rate limit counters: "memory" for a single instance, or
+	// "redis" to share them between instances
+	Store string
+	// Policies are the limits routes apply, by name. Each is configured with
+	// RATE_LIMIT_POLICY_<NAME>, in the environment or the .env file, e.g.
+	// RATE_LIMIT_POLICY_LOGIN=10/15m,algorithm=sliding_window,key=ip
+	Policies map[string]RateLimitPolicy
+}
+
+type RateLimitPolicy struct {
+	// Algorithm is "token_bucket" or "sliding_window"
+	Algorithm string
+	// Limit is how many requests are allowed per Window; 0 disables the policy
+	Limit  int
+	Window time.Duration
+	// Burst is the token bucket capacity, which defaults to Limit
+	Burst int
+	// Key identifies who is limited: "ip", "user" or "api_key"
+	Key string
+}
+
+// Rate limit stores for RateLimitConfig.Store
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// Algorithms for RateLimitPolicy.Algorithm
+const (
+	RateLimitTokenBucket   = "token_bucket"
+	RateLimitSlidingWindow = "sliding_window"
+)
+
+// Keys for RateLimitPolicy.Key
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyUser   = "user"
+	RateLimitKeyAPIKey = "api_key"
+)
+
+// rateLimitPolicyPrefix starts the name of each rate limit policy setting
+const rateLimitPolicyPrefix = "RATE_LIMIT_POLICY_"
+
+// defaultRateLimitPolicies are the policies the routes apply, overridable
+// with RATE_LIMIT_POLICY_<NAME>
+var defaultRateLimitPolicies = map[string]string{
+	// api applies to every API request
+	"api": "300/1m,algorithm=token_bucket,key=ip",
+	// login slows down password guessing
+	"login": "10/15m,algorithm=sliding_window,key=ip",
+	// refresh slows down guessing refresh tokens
+	"refresh": "30/15m,algorithm=sliding_window,key=ip",
+	// signup limits account creation
+	"signup": "5/1h,algorithm=sliding_window,key=ip",
+	// user applies to authenticated requests, per user
+	"user": "120/1m,algorithm=token_bucket,key=user",
 }
 
 func LoadConfig() *Config {
@@ -55,7 +237,7 @@ func LoadConfig() *Config {
 	if _, err := os.Stat(".env"); err == nil {
 		viper.SetConfigFile(".env")
 		if err := viper.ReadInConfig(); err != nil {
-			log.Printf("Warning: Error reading .env file: %v", err)
+			log.Warn().Err(err).Msg("Error reading .env file")
 		}
 	}
 
@@ -63,7 +245,7 @@ func LoadConfig() *Config {
 	if _, err := os.Stat("app.env"); err == nil {
 		viper.SetConfigFile("app.env")
 		if err := viper.ReadInConfig(); err != nil {
-			log.Printf("Warning: Error reading app.env file: %v", err)
+			log.Warn().Err(err).Msg("Error reading app.env file")
 		}
 	}
 
@@ -73,6 +255,10 @@ func LoadConfig() *Config {
 			Env:  getEnv("APP_ENV", "development"),
 			Name: getEnv("APP_NAME", "go-boilerplate"),
 		},
+		Proxy: ProxyConfig{
+			Header:         getEnv("PROXY_HEADER", ""),
+			TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -81,15 +267,64 @@ func LoadConfig() *Config {
 			Name:     getEnv("DB_NAME", "go_boilerplate"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 			URL:      getEnv("DATABASE_URL", ""),
+
+			MigrationMode: getEnv("DB_MIGRATION_MODE", MigrationModeApply),
+			AutoMigrate:   getEnvBool("DB_AUTO_MIGRATE", false),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:8080"), ","),
-			AllowedMethods: strings.Split(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"), ","),
-			AllowedHeaders: strings.Split(getEnv("CORS_ALLOWED_HEADERS", "Content-Type"), ","),
+			AllowedMethods: strings.Split(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"), ","),
+			AllowedHeaders: strings.Split(getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,If-Match,If-None-Match,X-Request-ID"), ","),
+			ExposedHeaders: strings.Split(getEnv("CORS_EXPOSED_HEADERS",
+				"ETag,Link,X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,Retry-After"), ","),
 		},
 		Log: LogConfig{
-			Level:  getEnv("LOG_LEVEL", "debug"),
-			Format: getEnv("LOG_FORMAT", "console"),
+			Level:              getEnv("LOG_LEVEL", "debug"),
+			Format:             getEnv("LOG_FORMAT", defaultLogFormat(getEnv("APP_ENV", "development"))),
+			SlowQueryThreshold: getEnvDuration("LOG_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+			RedactFields:       strings.Split(getEnv("LOG_REDACT_FIELDS", ""), ","),
+		},
+		JWT: JWTConfig{
+			Secret:          getEnv("JWT_SECRET", ""),
+			Issuer:          getEnv("JWT_ISSUER", getEnv("APP_NAME", "go-boilerplate")),
+			AccessTokenTTL:  getEnvDuration("JWT_EXPIRY", 15*time.Minute),
+			RefreshTokenTTL: getEnvDuration("JWT_REFRESH_EXPIRY", 30*24*time.Hour),
+		},
+		Pagination: PaginationConfig{
+			CursorSecret: getEnv("CURSOR_SECRET", ""),
+		},
+		Purge: PurgeConfig{
+			AfterDays: getEnvInt("PURGE_AFTER_DAYS", 30),
+			Interval:  getEnvDuration("PURGE_INTERVAL", time.Hour),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvBool("METRICS_ENABLED", true),
+			Path:    getEnv("METRICS_PATH", "/metrics"),
+			Addr:    getEnv("METRICS_ADDR", ""),
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", TracingExporterNone),
+			File:        getEnv("TRACING_FILE", ""),
+			SampleRatio: getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		Health: HealthConfig{
+			CheckTimeout: getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		},
+		Shutdown: ShutdownConfig{
+			Delay:   getEnvDuration("SHUTDOWN_DELAY", 0),
+			Timeout: getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		},
+		Redis: RedisConfig{
+			Host:     getEnv("REDIS_HOST", "localhost"),
+			Port:     getEnv("REDIS_PORT", "6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       getEnvInt("REDIS_DB", 0),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:  getEnvBool("RATE_LIMIT_ENABLED", true),
+			Store:    getEnv("RATE_LIMIT_STORE", RateLimitStoreMemory),
+			Policies: loadRateLimitPolicies(),
 		},
 	}
 
@@ -118,13 +353,185 @@ func setDefaults() {
 	viper.SetDefault("DB_PASSWORD", "password")
 	viper.SetDefault("DB_NAME", "go_boilerplate")
 	viper.SetDefault("DB_SSL_MODE", "disable")
+	viper.SetDefault("DB_MIGRATION_MODE", MigrationModeApply)
+	viper.SetDefault("DB_AUTO_MIGRATE", false)
 	viper.SetDefault("LOG_LEVEL", "debug")
-	viper.SetDefault("LOG_FORMAT", "console")
+	viper.SetDefault("LOG_SLOW_QUERY_THRESHOLD", "200ms")
+	viper.SetDefault("JWT_EXPIRY", "15m")
+	viper.SetDefault("JWT_REFRESH_EXPIRY", "720h")
+	viper.SetDefault("PURGE_AFTER_DAYS", 30)
+	viper.SetDefault("PURGE_INTERVAL", "1h")
+	viper.SetDefault("METRICS_ENABLED", true)
+	viper.SetDefault("METRICS_PATH", "/metrics")
+	viper.SetDefault("TRACING_EXPORTER", TracingExporterNone)
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1)
+	for name, spec := range defaultRateLimitPolicies {
+		viper.SetDefault(rateLimitPolicyPrefix+strings.ToUpper(name), spec)
+	}
+}
+
+// defaultLogFormat logs for humans in development and for log collectors elsewhere
+func defaultLogFormat(env string) string {
+	if env == "development" {
+		return logging.FormatConsole
+	}
+	return logging.FormatJSON
 }
 
+// loadRateLimitPolicies reads every policy set with RATE_LIMIT_POLICY_<NAME>
+// in the environment, the .env file or the defaults
+func loadRateLimitPolicies() map[string]RateLimitPolicy {
+	// AutomaticEnv only answers for keys viper already knows, so policies
+	// that are only set in the environment are bound to be listed
+	for _, env := range os.Environ() {
+		if key, _, _ := strings.Cut(env, "="); strings.HasPrefix(key, rateLimitPolicyPrefix) {
+			_ = viper.BindEnv(key)
+		}
+	}
+
+	specs := make(map[string]string)
+	prefix := strings.ToLower(rateLimitPolicyPrefix)
+	for _, key := range viper.AllKeys() {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		// An empty setting, such as RATE_LIMIT_POLICY_LOGIN= in .env, keeps the default
+		if spec := cmp.Or(viper.GetString(key), defaultRateLimitPolicies[name]); spec != "" {
+			specs[name] = spec
+		}
+	}
+
+	policies := make(map[string]RateLimitPolicy, len(specs))
+	for name, spec := range specs {
+		policy, err := parseRateLimitPolicy(spec)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Invalid rate limit policy for %s%s", rateLimitPolicyPrefix, strings.ToUpper(name))
+			if policy, err = parseRateLimitPolicy(defaultRateLimitPolicies[name]); err != nil {
+				continue
+			}
+		}
+		policies[name] = policy
+	}
+	return policies
+}
+
+// parseRateLimitPolicy parses a policy such as "10/15m,algorithm=sliding_window,key=ip".
+// The algorithm defaults to token_bucket and the key to ip; "off" disables the policy.
+func parseRateLimitPolicy(spec string) (RateLimitPolicy, error) {
+	policy := RateLimitPolicy{Algorithm: RateLimitTokenBucket, Key: RateLimitKeyIP}
+	if spec = strings.TrimSpace(spec); spec == "off" {
+		return policy, nil
+	}
+
+	rate, options, _ := strings.Cut(spec, ",")
+	limit, window, ok := strings.Cut(rate, "/")
+	if !ok {
+		return policy, fmt.Errorf("rate %q is not in limit/window form", rate)
+	}
+	var err error
+	if policy.Limit, err = strconv.Atoi(strings.TrimSpace(limit)); err != nil || policy.Limit < 0 {
+		return policy, fmt.Errorf("invalid limit %q", limit)
+	}
+	if policy.Window, err = time.ParseDuration(strings.TrimSpace(window)); err != nil || policy.Window < time.Second {
+		return policy, fmt.Errorf("invalid window %q", window)
+	}
+
+	for _, option := range strings.Split(options, ",") {
+		if option = strings.TrimSpace(option); option == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(option, "=")
+		switch name {
+		case "algorithm":
+			if value != RateLimitTokenBucket && value != RateLimitSlidingWindow {
+				return policy, fmt.Errorf("unknown algorithm %q", value)
+			}
+			policy.Algorithm = value
+		case "key":
+			if value != RateLimitKeyIP && value != RateLimitKeyUser && value != RateLimitKeyAPIKey {
+				return policy, fmt.Errorf("unknown key %q", value)
+			}
+			policy.Key = value
+		case "burst":
+			if policy.Burst, err = strconv.Atoi(value); err != nil || policy.Burst < 0 {
+				return policy, fmt.Errorf("invalid burst %q", value)
+			}
+		default:
+			return policy, fmt.Errorf("unknown option %q", name)
+		}
+	}
+	return policy, nil
+}
+
+// getEnv reads a setting from the environment or, failing that, from the
+// .env or app.env file
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := viper.GetString(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma-separated setting, dropping empty items
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := viper.GetString(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Invalid duration for %s", key)
+		return defaultValue
+	}
+	return duration
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := viper.GetString(key)
+	if value == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		log.Warn().Str("value", value).Msgf("Invalid non-negative integer for %s", key)
+		return defaultValue
+	}
+	return i
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := viper.GetString(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Invalid number for %s", key)
+		return defaultValue
+	}
+	return f
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := viper.GetString(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Invalid boolean for %s", key)
+		return defaultValue
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// loadConfigFrom loads the config in a directory holding the given .env file
+func loadConfigFrom(t *testing.T, dotenv string) *Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(dotenv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	return LoadConfig()
+}
+
+func TestLoadRateLimitPolicies(t *testing.T) {
+	t.Setenv("RATE_LIMIT_POLICY_SIGNUP", "off")
+	t.Setenv("RATE_LIMIT_POLICY_EXPORT", "5/1m,key=api_key")
+	t.Setenv("RATE_LIMIT_STORE", "memory")
+
+	cfg := loadConfigFrom(t, `RATE_LIMIT_ENABLED=true
+RATE_LIMIT_POLICY_LOGIN=3/1m,algorithm=sliding_window
+RATE_LIMIT_POLICY_REPORTS=20/1h,key=user
+RATE_LIMIT_POLICY_USER=
+RATE_LIMIT_POLICY_SIGNUP=7/1h
+RATE_LIMIT_POLICY_API=not-a-policy
+`)
+
+	tests := []struct {
+		name string
+		want RateLimitPolicy
+	}{
+		{"refresh", RateLimitPolicy{Algorithm: RateLimitSlidingWindow, Limit: 30, Window: 15 * time.Minute, Key: RateLimitKeyIP}},
+		{"login", RateLimitPolicy{Algorithm: RateLimitSlidingWindow, Limit: 3, Window: time.Minute, Key: RateLimitKeyIP}},
+		{"reports", RateLimitPolicy{Algorithm: RateLimitTokenBucket, Limit: 20, Window: time.Hour, Key: RateLimitKeyUser}},
+		{"user", RateLimitPolicy{Algorithm: RateLimitTokenBucket, Limit: 120, Window: time.Minute, Key: RateLimitKeyUser}},
+		// The environment takes precedence over .env
+		{"signup", RateLimitPolicy{Algorithm: RateLimitTokenBucket, Key: RateLimitKeyIP}},
+		{"export", RateLimitPolicy{Algorithm: RateLimitTokenBucket, Limit: 5, Window: time.Minute, Key: RateLimitKeyAPIKey}},
+		// Invalid policies fall back to the default
+		{"api", RateLimitPolicy{Algorithm: RateLimitTokenBucket, Limit: 300, Window: time.Minute, Key: RateLimitKeyIP}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := cfg.RateLimit.Policies[tt.name]; !ok || got != tt.want {
+				t.Errorf("Policies[%q] = %+v, %v, want %+v", tt.name, got, ok, tt.want)
+			}
+		})
+	}
+	if len(cfg.RateLimit.Policies) != len(tests) {
+		t.Errorf("got %d policies, want %d: %+v", len(cfg.RateLimit.Policies), len(tests), cfg.RateLimit.Policies)
+	}
+}
+
+func TestLoadConfigReadsDotEnv(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+
+	cfg := loadConfigFrom(t, `APP_PORT=9090
+APP_ENV=staging
+JWT_EXPIRY=5m
+METRICS_ENABLED=false
+TRUSTED_PROXIES=10.0.0.0/8, 10.1.0.1
+`)
+
+	if cfg.App.Port != "9090" {
+		t.Errorf("App.Port = %q, want the .env value 9090", cfg.App.Port)
+	}
+	if cfg.App.Env != "production" {
+		t.Errorf("App.Env = %q, want the environment to take precedence", cfg.App.Env)
+	}
+	if cfg.JWT.AccessTokenTTL != 5*time.Minute {
+		t.Errorf("JWT.AccessTokenTTL = %s, want 5m", cfg.JWT.AccessTokenTTL)
+	}
+	if cfg.Metrics.Enabled {
+		t.Error("Metrics.Enabled = true, want false")
+	}
+	if got := strings.Join(cfg.Proxy.TrustedProxies, ","); got != "10.0.0.0/8,10.1.0.1" {
+		t.Errorf("Proxy.TrustedProxies = %q", got)
+	}
+	if cfg.Database.Host != "localhost" {
+		t.Errorf("Database.Host = %q, want the default", cfg.Database.Host)
+	}
+}
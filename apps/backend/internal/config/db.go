@@ -3,28 +3,28 @@ package config
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/albuquerquewizard/monorepo/backend/internal/logging"
+	"github.com/rs/zerolog"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type Database struct {
 	DB *gorm.DB
 }
 
-func NewDatabase(config *Config) *Database {
-	db, err := connectDB(config)
+func NewDatabase(config *Config, logger zerolog.Logger) *Database {
+	db, err := connectDB(config, logger)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		logger.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 
 	// Configure GORM
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to get underlying sql.DB: %v", err)
+		logger.Fatal().Err(err).Msg("Failed to get underlying sql.DB")
 	}
 
 	// Set connection pool settings
@@ -34,15 +34,15 @@ func NewDatabase(config *Config) *Database {
 
 	// Test the connection
 	if err := testConnection(db); err != nil {
-		log.Fatalf("Failed to test database connection: %v", err)
+		logger.Fatal().Err(err).Msg("Failed to test database connection")
 	}
 
-	log.Println("✅ Database connected successfully")
+	logger.Info().Msg("✅ Database connected successfully")
 
 	return &Database{DB: db}
 }
 
-func connectDB(config *Config) (*gorm.DB, error) {
+func connectDB(config *Config, appLogger zerolog.Logger) (*gorm.DB, error) {
 	dsn := config.Database.URL
 	if dsn == "" {
 		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
@@ -55,23 +55,12 @@ func connectDB(config *Config) (*gorm.DB, error) {
 		)
 	}
 
-	// Configure GORM logger based on environment
-	var logLevel logger.LogLevel
-	switch config.Log.Level {
-	case "debug":
-		logLevel = logger.Info
-	case "info":
-		logLevel = logger.Warn
-	case "warn":
-		logLevel = logger.Error
-	case "error":
-		logLevel = logger.Error
-	default:
-		logLevel = logger.Warn
-	}
-
+	// GORM logs through the application logger, which filters by LOG_LEVEL;
+	// query arguments are only logged in development
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: logging.NewGormLogger(appLogger, config.Log.SlowQueryThreshold, config.App.Env == "development"),
+		// Report constraint violations as gorm.ErrDuplicatedKey and friends
+		TranslateError: true,
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
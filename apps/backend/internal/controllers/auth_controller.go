@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"github.com/albuquerquewizard/monorepo/backend/internal/dto"
+	"github.com/albuquerquewizard/monorepo/backend/internal/middleware"
+	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthController handles HTTP requests for authentication
+type AuthController struct {
+	authService services.AuthService
+}
+
+// NewAuthController creates a new auth controller
+func NewAuthController(authService services.AuthService) *AuthController {
+	return &AuthController{
+		authService: authService,
+	}
+}
+
+// Login handles POST /api/auth/login
+func (c *AuthController) Login(ctx *fiber.Ctx) error {
+	var req dto.LoginRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ErrInvalidBody
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	user, tokens, err := c.authService.Login(ctx.UserContext(), req.Username, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(ctx, "Login successful", fiber.Map{
+		"user":   dto.NewUserResponse(user),
+		"tokens": tokens,
+	})
+}
+
+// Refresh handles POST /api/auth/refresh
+func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
+	var req dto.RefreshTokenRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ErrInvalidBody
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	tokens, err := c.authService.Refresh(ctx.UserContext(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(ctx, "Token refreshed successfully", fiber.Map{
+		"tokens": tokens,
+	})
+}
+
+// Logout handles POST /api/auth/logout
+func (c *AuthController) Logout(ctx *fiber.Ctx) error {
+	var req dto.RefreshTokenRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ErrInvalidBody
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	if err := c.authService.Logout(ctx.UserContext(), req.RefreshToken); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(ctx, "Logged out successfully", nil)
+}
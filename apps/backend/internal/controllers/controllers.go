@@ -1,19 +1,64 @@
 package controllers
 
 import (
+	"strconv"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
 	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Request errors shared by all controllers
+var (
+	ErrInvalidBody = apperrors.BadRequest("invalid_body", "Invalid request body")
+	ErrInvalidID   = apperrors.BadRequest("invalid_id", "Invalid ID")
+
+	// ErrETagMismatch is returned when If-Match does not match the current version
+	ErrETagMismatch = apperrors.PreconditionFailed("etag_mismatch",
+		"The resource has changed since you last retrieved it")
 )
 
 // Controllers holds all controller instances
 type Controllers struct {
 	User *UserController
+	Auth *AuthController
 	// Add more controllers here as you create them
 }
 
 // NewControllers creates a new Controllers instance with all controllers
-func NewControllers(services *services.Services) *Controllers {
+func NewControllers(services *services.Services, cursors *pagination.Codec) *Controllers {
 	return &Controllers{
-		User: NewUserController(services.User),
+		User: NewUserController(services.User, cursors),
+		Auth: NewAuthController(services.Auth),
 		// Add more controllers here as you create them
 	}
 }
+
+// parseID reads the :id route parameter
+func parseID(ctx *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return 0, ErrInvalidID
+	}
+	return uint(id), nil
+}
+
+// checkIfMatch enforces an If-Match precondition against the current entity tag.
+// Requests without If-Match are allowed through unconditionally.
+func checkIfMatch(ctx *fiber.Ctx, etag string) error {
+	ifMatch := ctx.Get(fiber.HeaderIfMatch)
+	if ifMatch != "" && !utils.MatchesIfMatch(ifMatch, etag) {
+		return ErrETagMismatch
+	}
+	return nil
+}
+
+// setETag sets the ETag header and reports whether the client's If-None-Match
+// already matches it, in which case a 304 should be sent instead of the body
+func setETag(ctx *fiber.Ctx, etag string) (notModified bool) {
+	ctx.Set(fiber.HeaderETag, etag)
+	ifNoneMatch := ctx.Get(fiber.HeaderIfNoneMatch)
+	return ifNoneMatch != "" && utils.MatchesIfNoneMatch(ifNoneMatch, etag)
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/middleware"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CRUDRequest is the request body of a CRUD resource. It declares exactly the
+// fields clients may write and copies them onto the model, so that fields
+// managed by the server can never be set through a request body.
+type CRUDRequest[T, R any] interface {
+	*R
+	ApplyTo(entity *T)
+}
+
+// CRUDController serves the standard REST endpoints for any model embedding
+// models.BaseModel. Request bodies bind to the request type R rather than to
+// the model, so only its fields are writable; the base fields (id,
+// timestamps, version) always come from the server.
+type CRUDController[T, R any, PT repositories.Model[T], PR CRUDRequest[T, R]] struct {
+	service services.CRUDService[T]
+	schema  *query.Schema
+	cursors *pagination.Codec
+	name    string
+}
+
+// NewCRUDController creates a generic controller. Name is the singular,
+// capitalized resource name used in messages, e.g. "Product".
+func NewCRUDController[T, R any, PT repositories.Model[T], PR CRUDRequest[T, R]](
+	service services.CRUDService[T],
+	schema *query.Schema,
+	cursors *pagination.Codec,
+	name string,
+) *CRUDController[T, R, PT, PR] {
+	return &CRUDController[T, R, PT, PR]{
+		service: service,
+		schema:  schema,
+		cursors: cursors,
+		name:    name,
+	}
+}
+
+// Mount registers the CRUD routes on router. Handlers such as auth run
+// before every route, followed by the permission check for the route's
+// action, so permissions must be mounted after RequireAuth.
+func (c *CRUDController[T, R, PT, PR]) Mount(router fiber.Router, permissions models.ResourcePermissions, handlers ...fiber.Handler) {
+	with := func(permission string, handler fiber.Handler) []fiber.Handler {
+		chain := slices.Clip(handlers)
+		if permission != "" {
+			chain = append(chain, middleware.RequirePermission(permission))
+		}
+		return append(chain, handler)
+	}
+
+	router.Get("/", with(permissions.Read, c.List)...)
+	router.Post("/", with(permissions.Create, c.Create)...)
+	router.Get("/:id", with(permissions.Read, c.Get)...)
+	router.Put("/:id", with(permissions.Update, c.Update)...)
+	router.Patch("/:id", with(permissions.Update, c.Patch)...)
+	router.Delete("/:id", with(permissions.Delete, c.Delete)...)
+}
+
+// Create handles POST /
+func (c *CRUDController[T, R, PT, PR]) Create(ctx *fiber.Ctx) error {
+	var req R
+	if err := ctx.BodyParser(&req); err != nil {
+		return ErrInvalidBody
+	}
+
+	var entity T
+	PR(&req).ApplyTo(&entity)
+
+	if err := c.service.Create(ctx.UserContext(), &entity); err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, c.etag(&entity))
+	return utils.SuccessResponse(ctx, c.name+" created successfully", entity)
+}
+
+// Get handles GET /:id
+func (c *CRUDController[T, R, PT, PR]) Get(ctx *fiber.Ctx) error {
+	entity, err := c.find(ctx)
+	if err != nil {
+		return err
+	}
+
+	if setETag(ctx, c.etag(entity)) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	return utils.SuccessResponse(ctx, c.name+" retrieved successfully", entity)
+}
+
+// Update handles PUT /:id, replacing every writable field of the entity
+func (c *CRUDController[T, R, PT, PR]) Update(ctx *fiber.Ctx) error {
+	existing, err := c.find(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ctx, c.etag(existing)); err != nil {
+		return err
+	}
+
+	var req R
+	if err := ctx.BodyParser(&req); err != nil {
+		return ErrInvalidBody
+	}
+
+	entity := *existing
+	PR(&req).ApplyTo(&entity)
+	return c.replace(ctx, existing, &entity)
+}
+
+// Patch handles PATCH /:id with a JSON Merge Patch (RFC 7396) body
+func (c *CRUDController[T, R, PT, PR]) Patch(ctx *fiber.Ctx) error {
+	if !isMergePatch(ctx) {
+		return ErrUnsupportedPatch
+	}
+
+	existing, err := c.find(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ctx, c.etag(existing)); err != nil {
+		return err
+	}
+
+	current, err := json.Marshal(existing)
+	if err != nil {
+		return err
+	}
+	merged, err := utils.MergePatch(current, ctx.Body())
+	if err != nil {
+		return ErrInvalidBody
+	}
+
+	// Fields outside the request type are dropped here, so patching them has no effect
+	var req R
+	if err := json.Unmarshal(merged, &req); err != nil {
+		return ErrInvalidBody
+	}
+
+	entity := *existing
+	PR(&req).ApplyTo(&entity)
+	return c.replace(ctx, existing, &entity)
+}
+
+// Delete handles DELETE /:id
+func (c *CRUDController[T, R, PT, PR]) Delete(ctx *fiber.Ctx) error {
+	entity, err := c.find(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ctx, c.etag(entity)); err != nil {
+		return err
+	}
+
+	if err := c.service.Delete(ctx.UserContext(), entity); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(ctx, c.name+" deleted successfully", nil)
+}
+
+// List handles GET / with filtering, sorting and pagination
+func (c *CRUDController[T, R, PT, PR]) List(ctx *fiber.Ctx) error {
+	params, err := pagination.Parse(ctx.Queries())
+	if err != nil {
+		return err
+	}
+
+	spec, err := query.Parse(ctx.Queries(), c.schema)
+	if err != nil {
+		return err
+	}
+
+	message := c.name + " list retrieved successfully"
+
+	if params.Mode == pagination.ModeCursor {
+		desc, err := keysetDirection(spec)
+		if err != nil {
+			return err
+		}
+		keyset, err := c.cursors.Keyset(params, desc)
+		if err != nil {
+			return err
+		}
+
+		page, err := c.service.ListPage(ctx.UserContext(), spec, keyset)
+		if err != nil {
+			return err
+		}
+		return utils.PaginatedResponse(ctx, message, page.Items, cursorPagination(params, page, c.cursors), spec.Meta())
+	}
+
+	entities, total, err := c.service.List(ctx.UserContext(), spec, params.Offset, params.Limit)
+	if err != nil {
+		return err
+	}
+	return utils.PaginatedResponse(ctx, message, entities, offsetPagination(params, total, len(entities)), spec.Meta())
+}
+
+// find loads the entity named by the :id route parameter
+func (c *CRUDController[T, R, PT, PR]) find(ctx *fiber.Ctx) (*T, error) {
+	id, err := parseID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.service.Get(ctx.UserContext(), id)
+}
+
+// replace stores entity as the new state of existing, keeping the base fields of existing
+func (c *CRUDController[T, R, PT, PR]) replace(ctx *fiber.Ctx, existing, entity *T) error {
+	*PT(entity).Base() = *PT(existing).Base()
+
+	if err := c.service.Update(ctx.UserContext(), entity); err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, c.etag(entity))
+	return utils.SuccessResponse(ctx, c.name+" updated successfully", entity)
+}
+
+// etag returns the entity tag for the current version of an entity
+func (c *CRUDController[T, R, PT, PR]) etag(entity *T) string {
+	base := PT(entity).Base()
+	return utils.VersionETag(base.ID, base.Version)
+}
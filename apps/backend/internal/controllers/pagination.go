@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
+	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+)
+
+// keysetSortField is the only field cursor pagination can sort by, since cursors walk (created_at, id)
+const keysetSortField = "created_at"
+
+// keysetDirection returns the sort direction for cursor pagination, which
+// requires the list to be sorted by created_at alone
+func keysetDirection(spec *query.Spec) (desc bool, err error) {
+	sortKey, ok := spec.SingleSort()
+	if !ok || sortKey.Field != keysetSortField {
+		return false, apperrors.Validation("Invalid query parameters", map[string]string{
+			query.SortParam: "cursor pagination only supports sort=created_at or sort=-created_at",
+		})
+	}
+	return sortKey.Desc, nil
+}
+
+// offsetPagination describes a page or offset mode page of count items out of total
+func offsetPagination(params *pagination.Params, total int64, count int) utils.Pagination {
+	offset := params.Offset
+	result := utils.Pagination{
+		Mode:    string(params.Mode),
+		Limit:   params.Limit,
+		Total:   &total,
+		Offset:  &offset,
+		HasNext: int64(offset+count) < total,
+		HasPrev: offset > 0,
+	}
+
+	if params.Mode == pagination.ModePage {
+		result.Page = params.Page
+		result.Pages = utils.TotalPages(total, params.Limit)
+		if result.HasNext {
+			result.NextLink = setParam(pagination.PageParam, params.Page+1)
+		}
+		if result.HasPrev {
+			result.PrevLink = setParam(pagination.PageParam, params.Page-1)
+		}
+		return result
+	}
+
+	if result.HasNext {
+		result.NextLink = setParam(pagination.OffsetParam, offset+params.Limit)
+	}
+	if result.HasPrev {
+		result.PrevLink = setParam(pagination.OffsetParam, max(offset-params.Limit, 0))
+	}
+	return result
+}
+
+// cursorPagination describes a cursor mode page, encoding its cursors with codec
+func cursorPagination[T any](params *pagination.Params, page *pagination.Page[T], codec *pagination.Codec) utils.Pagination {
+	result := utils.Pagination{
+		Mode:           string(pagination.ModeCursor),
+		Limit:          params.Limit,
+		Total:          page.Total,
+		TotalEstimated: page.TotalEstimated,
+		HasNext:        page.Next != nil,
+		HasPrev:        page.Prev != nil,
+	}
+
+	if page.Next != nil {
+		result.NextCursor = codec.Encode(*page.Next)
+		result.NextLink = &utils.PageLink{
+			Set:    map[string]string{pagination.AfterParam: result.NextCursor},
+			Remove: []string{pagination.BeforeParam},
+		}
+	}
+	if page.Prev != nil {
+		result.PrevCursor = codec.Encode(*page.Prev)
+		result.PrevLink = &utils.PageLink{
+			Set:    map[string]string{pagination.BeforeParam: result.PrevCursor},
+			Remove: []string{pagination.AfterParam},
+		}
+	}
+	return result
+}
+
+// setParam returns a link to the same list with one integer parameter changed
+func setParam(name string, value int) *utils.PageLink {
+	return &utils.PageLink{Set: map[string]string{name: strconv.Itoa(value)}}
+}
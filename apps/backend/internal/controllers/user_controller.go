@@ -1,136 +1,327 @@
 package controllers
 
 import (
-	"strconv"
+	"encoding/json"
+	"strings"
 
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/dto"
+	"github.com/albuquerquewizard/monorepo/backend/internal/middleware"
 	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
 	"github.com/albuquerquewizard/monorepo/backend/internal/services"
 	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
+// User request errors
+var (
+	// ErrActiveStateForbidden is returned when a caller without the users:update
+	// permission tries to activate or deactivate an account
+	ErrActiveStateForbidden = apperrors.Forbidden("active_state_forbidden",
+		"You do not have permission to change whether this account is active")
+
+	// ErrUnsupportedPatch is returned when a PATCH body is not a JSON merge patch
+	ErrUnsupportedPatch = fiber.NewError(fiber.StatusUnsupportedMediaType,
+		"PATCH requests must use Content-Type application/merge-patch+json or application/json")
+)
+
 // UserController handles HTTP requests for user operations
 type UserController struct {
 	userService services.UserService
+	cursors     *pagination.Codec
 }
 
 // NewUserController creates a new user controller
-func NewUserController(userService services.UserService) *UserController {
+func NewUserController(userService services.UserService, cursors *pagination.Codec) *UserController {
 	return &UserController{
 		userService: userService,
+		cursors:     cursors,
 	}
 }
 
 // CreateUser handles POST /api/users
 func (c *UserController) CreateUser(ctx *fiber.Ctx) error {
-	var user models.User
-
-	if err := ctx.BodyParser(&user); err != nil {
-		return utils.ErrorResponse(ctx, fiber.StatusBadRequest, "Invalid request body")
+	var req dto.CreateUserRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ErrInvalidBody
 	}
 
-	// Validate required fields
-	if user.Username == "" || user.Password == "" {
-		return utils.ErrorResponse(ctx, fiber.StatusBadRequest, "Username and password are required")
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return err
 	}
 
+	user := req.ToModel()
+
 	// Create user
-	if err := c.userService.CreateUser(ctx.Context(), &user); err != nil {
-		return utils.ErrorResponse(ctx, fiber.StatusInternalServerError, err.Error())
+	if err := c.userService.CreateUser(ctx.UserContext(), user); err != nil {
+		return err
 	}
 
-	// Don't return password in response
-	user.Password = ""
-
-	return utils.SuccessResponse(ctx, "User created successfully", user)
+	return utils.SuccessResponse(ctx, "User created successfully", dto.NewUserResponse(user))
 }
 
 // GetUser handles GET /api/users/:id
 func (c *UserController) GetUser(ctx *fiber.Ctx) error {
-	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	id, err := parseID(ctx)
 	if err != nil {
-		return utils.ErrorResponse(ctx, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 
-	user, err := c.userService.GetUserByID(ctx.Context(), uint(id))
+	user, err := c.userService.GetUserByID(ctx.UserContext(), id)
 	if err != nil {
-		if err.Error() == "user not found" {
-			return utils.ErrorResponse(ctx, fiber.StatusNotFound, "User not found")
-		}
-		return utils.ErrorResponse(ctx, fiber.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	if setETag(ctx, userETag(user)) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	return utils.SuccessResponse(ctx, "User retrieved successfully", dto.NewUserResponse(user))
+}
+
+// GetMe handles GET /api/me
+func (c *UserController) GetMe(ctx *fiber.Ctx) error {
+	user, ok := middleware.CurrentUser(ctx)
+	if !ok {
+		return middleware.ErrUnauthorized
+	}
+
+	if setETag(ctx, userETag(user)) {
+		return ctx.SendStatus(fiber.StatusNotModified)
 	}
 
-	return utils.SuccessResponse(ctx, "User retrieved successfully", user)
+	return utils.SuccessResponse(ctx, "User retrieved successfully", dto.NewUserResponse(user))
 }
 
 // UpdateUser handles PUT /api/users/:id
 func (c *UserController) UpdateUser(ctx *fiber.Ctx) error {
-	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateUserRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ErrInvalidBody
+	}
+
+	user, err := c.userService.GetUserByID(ctx.UserContext(), id)
 	if err != nil {
-		return utils.ErrorResponse(ctx, fiber.StatusBadRequest, "Invalid user ID")
+		return err
+	}
+	if err := checkIfMatch(ctx, userETag(user)); err != nil {
+		return err
 	}
 
-	var user models.User
-	if err := ctx.BodyParser(&user); err != nil {
-		return utils.ErrorResponse(ctx, fiber.StatusBadRequest, "Invalid request body")
+	if err := c.replaceUser(ctx, user, &req); err != nil {
+		return err
 	}
+	ctx.Set(fiber.HeaderETag, userETag(user))
 
-	user.ID = uint(id)
+	return utils.SuccessResponse(ctx, "User updated successfully", dto.NewUserResponse(user))
+}
 
-	if err := c.userService.UpdateUser(ctx.Context(), &user); err != nil {
-		if err.Error() == "user not found" {
-			return utils.ErrorResponse(ctx, fiber.StatusNotFound, "User not found")
-		}
-		return utils.ErrorResponse(ctx, fiber.StatusInternalServerError, err.Error())
+// PatchUser handles PATCH /api/users/:id with a JSON Merge Patch (RFC 7396) body
+func (c *UserController) PatchUser(ctx *fiber.Ctx) error {
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Don't return password in response
-	user.Password = ""
+	if !isMergePatch(ctx) {
+		return ErrUnsupportedPatch
+	}
+
+	user, err := c.userService.GetUserByID(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ctx, userETag(user)); err != nil {
+		return err
+	}
+
+	// Merge the patch onto the current representation, so omitted fields keep their value
+	current, err := json.Marshal(dto.NewUpdateUserRequest(user))
+	if err != nil {
+		return err
+	}
+	merged, err := utils.MergePatch(current, ctx.Body())
+	if err != nil {
+		return ErrInvalidBody
+	}
+
+	var req dto.UpdateUserRequest
+	if err := json.Unmarshal(merged, &req); err != nil {
+		return ErrInvalidBody
+	}
 
-	return utils.SuccessResponse(ctx, "User updated successfully", user)
+	if err := c.replaceUser(ctx, user, &req); err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderETag, userETag(user))
+
+	return utils.SuccessResponse(ctx, "User updated successfully", dto.NewUserResponse(user))
+}
+
+// replaceUser validates req and stores it as the new state of user
+func (c *UserController) replaceUser(ctx *fiber.Ctx, user *models.User, req *dto.UpdateUserRequest) error {
+	if err := middleware.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	if *req.IsActive != user.IsActive && !canChangeActiveState(ctx) {
+		return ErrActiveStateForbidden
+	}
+
+	// A stolen access token must not be enough to take over the account
+	if req.Password != "" && isCurrentUser(ctx, user) {
+		if err := c.userService.VerifyPassword(ctx.UserContext(), user, req.CurrentPassword); err != nil {
+			return err
+		}
+	}
+
+	req.ApplyTo(user)
+	return c.userService.UpdateUser(ctx.UserContext(), user)
 }
 
 // DeleteUser handles DELETE /api/users/:id
 func (c *UserController) DeleteUser(ctx *fiber.Ctx) error {
-	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	id, err := parseID(ctx)
 	if err != nil {
-		return utils.ErrorResponse(ctx, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 
-	if err := c.userService.DeleteUser(ctx.Context(), uint(id)); err != nil {
-		if err.Error() == "user not found" {
-			return utils.ErrorResponse(ctx, fiber.StatusNotFound, "User not found")
-		}
-		return utils.ErrorResponse(ctx, fiber.StatusInternalServerError, err.Error())
+	user, err := c.userService.GetUserByID(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ctx, userETag(user)); err != nil {
+		return err
+	}
+
+	if err := c.userService.DeleteUser(ctx.UserContext(), user); err != nil {
+		return err
 	}
 
 	return utils.SuccessResponse(ctx, "User deleted successfully", nil)
 }
 
+// RestoreUser handles POST /api/users/:id/restore
+func (c *UserController) RestoreUser(ctx *fiber.Ctx) error {
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.userService.GetUserByIDWithTrashed(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ctx, userETag(user)); err != nil {
+		return err
+	}
+
+	if err := c.userService.RestoreUser(ctx.UserContext(), user); err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, userETag(user))
+	return utils.SuccessResponse(ctx, "User restored successfully", dto.NewUserResponse(user))
+}
+
+// HardDeleteUser handles DELETE /api/users/:id/permanent, removing the user
+// for good whether or not it was soft-deleted first
+func (c *UserController) HardDeleteUser(ctx *fiber.Ctx) error {
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.userService.GetUserByIDWithTrashed(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ctx, userETag(user)); err != nil {
+		return err
+	}
+
+	if err := c.userService.HardDeleteUser(ctx.UserContext(), user); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(ctx, "User permanently deleted", nil)
+}
+
 // ListUsers handles GET /api/users
 func (c *UserController) ListUsers(ctx *fiber.Ctx) error {
-	offset, _ := strconv.Atoi(ctx.Query("offset", "0"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.Parse(ctx.Queries())
+	if err != nil {
+		return err
+	}
+
+	spec, err := query.Parse(ctx.Queries(), repositories.UserQuery)
+	if err != nil {
+		return err
+	}
 
-	// Validate pagination parameters
-	if limit > 100 {
-		limit = 100
+	if params.Mode == pagination.ModeCursor {
+		return c.listUsersPage(ctx, spec, params)
 	}
-	if offset < 0 {
-		offset = 0
+
+	users, total, err := c.userService.ListUsers(ctx.UserContext(), spec, params.Offset, params.Limit)
+	if err != nil {
+		return err
 	}
 
-	users, total, err := c.userService.ListUsers(ctx.Context(), offset, limit)
+	return utils.PaginatedResponse(ctx, "Users retrieved successfully", dto.NewUserResponses(users),
+		offsetPagination(params, total, len(users)), spec.Meta())
+}
+
+// listUsersPage responds with a cursor paginated page of users
+func (c *UserController) listUsersPage(ctx *fiber.Ctx, spec *query.Spec, params *pagination.Params) error {
+	desc, err := keysetDirection(spec)
 	if err != nil {
-		return utils.ErrorResponse(ctx, fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	response := fiber.Map{
-		"users":  users,
-		"total":  total,
-		"offset": offset,
-		"limit":  limit,
+	keyset, err := c.cursors.Keyset(params, desc)
+	if err != nil {
+		return err
 	}
 
-	return utils.SuccessResponse(ctx, "Users retrieved successfully", response)
+	page, err := c.userService.ListUsersPage(ctx.UserContext(), spec, keyset)
+	if err != nil {
+		return err
+	}
+
+	return utils.PaginatedResponse(ctx, "Users retrieved successfully", dto.NewUserResponses(page.Items),
+		cursorPagination(params, page, c.cursors), spec.Meta())
+}
+
+// canChangeActiveState reports whether the current user may set is_active.
+// Users may edit their own profile, but only administrators may (de)activate accounts.
+func canChangeActiveState(ctx *fiber.Ctx) bool {
+	user, ok := middleware.CurrentUser(ctx)
+	return ok && user.HasPermission(models.PermissionUsersUpdate)
+}
+
+// isCurrentUser reports whether user is the authenticated user
+func isCurrentUser(ctx *fiber.Ctx, user *models.User) bool {
+	current, ok := middleware.CurrentUser(ctx)
+	return ok && current.ID == user.ID
+}
+
+// isMergePatch reports whether the request body is declared as a JSON merge patch
+func isMergePatch(ctx *fiber.Ctx) bool {
+	contentType, _, _ := strings.Cut(ctx.Get(fiber.HeaderContentType), ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	return contentType == utils.MergePatchContentType || contentType == fiber.MIMEApplicationJSON
+}
+
+// userETag returns the entity tag for the current version of a user
+func userETag(user *models.User) string {
+	return utils.VersionETag(user.ID, user.Version)
 }
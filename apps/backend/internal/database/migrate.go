@@ -0,0 +1,396 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey identifies the Postgres advisory lock held while migrating,
+// so that replicas starting at the same time apply migrations one at a time
+const migrationLockKey int64 = 8_364_121_907_552_004
+
+// migrationFilePattern matches files such as 000001_create_users.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrChecksumMismatch is returned when an applied migration was edited after it ran
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// ErrUnknownMigration is returned when the database has a migration this build does not know about
+var ErrUnknownMigration = errors.New("unknown migration applied to database")
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// appliedMigration is a row of the schema_migrations table
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies the embedded SQL migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations returns every known migration in version order
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies all pending migrations and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		pending, err := m.pending(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range pending {
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the given number of most recently applied migrations and
+// returns the ones it rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for i := len(applied) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			migration := m.find(applied[i].Version)
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			if err := m.revert(ctx, conn, *migration); err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, *migration)
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	appliedByVersion := make(map[int64]appliedMigration, len(applied))
+	for _, a := range applied {
+		appliedByVersion[a.Version] = a
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if a, ok := appliedByVersion[migration.Version]; ok {
+			appliedAt := a.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Unapplied returns the known migrations that have not been applied yet.
+// It only reads the database and tolerates applied migrations this build
+// does not know about, which a newer replica may have applied during a
+// rolling deployment, so it suits startup checks and health checks.
+func (m *Migrator) Unapplied(ctx context.Context) ([]Migration, error) {
+	var exists bool
+	if err := m.db.QueryRowContext(ctx,
+		"SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return m.Migrations(), nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]bool, len(m.migrations))
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		done[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var unapplied []Migration
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			unapplied = append(unapplied, migration)
+		}
+	}
+	return unapplied, nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		if _, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", unlockErr)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// pending returns the known migrations that are not recorded as applied
+func (m *Migrator) pending(ctx context.Context, conn *sql.Conn) ([]Migration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int64]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// applied reads the schema_migrations table, creating it if needed
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) ([]appliedMigration, error) {
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   CHAR(64) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx,
+		"SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []appliedMigration
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// verify checks that every applied migration is known and unchanged
+func (m *Migrator) verify(applied []appliedMigration) error {
+	for _, a := range applied {
+		migration := m.find(a.Version)
+		if migration == nil {
+			return fmt.Errorf("%w: %d_%s", ErrUnknownMigration, a.Version, a.Name)
+		}
+		if migration.Checksum != a.Checksum {
+			return fmt.Errorf("%w: %d_%s was modified after it was applied", ErrChecksumMismatch, a.Version, a.Name)
+		}
+	}
+	return nil
+}
+
+// apply runs an up migration and records it, in a single transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			migration.Version, migration.Name, migration.Checksum)
+		return err
+	})
+}
+
+// revert runs a down migration and removes its record, in a single transaction
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		return err
+	})
+}
+
+// find returns the known migration with the given version, or nil
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction on conn, committing only if it succeeds
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadMigrations reads and pairs the up/down files in dir
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			migration.Up = string(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
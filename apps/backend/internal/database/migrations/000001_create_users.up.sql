@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    username   VARCHAR(100) NOT NULL,
+    password   VARCHAR(255) NOT NULL,
+    first_name VARCHAR(100),
+    last_name  VARCHAR(100),
+    is_active  BOOLEAN DEFAULT TRUE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
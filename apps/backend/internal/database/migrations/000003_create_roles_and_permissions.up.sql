@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    id          BIGSERIAL PRIMARY KEY,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ,
+    name        VARCHAR(100) NOT NULL,
+    description VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_name ON permissions (name);
+CREATE INDEX IF NOT EXISTS idx_permissions_deleted_at ON permissions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id          BIGSERIAL PRIMARY KEY,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ,
+    name        VARCHAR(50) NOT NULL,
+    description VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+CREATE INDEX IF NOT EXISTS idx_roles_deleted_at ON roles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id       BIGINT NOT NULL,
+    permission_id BIGINT NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    PRIMARY KEY (user_id, role_id),
+    CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_roles_role FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+ALTER TABLE permissions DROP COLUMN IF EXISTS version;
+ALTER TABLE roles DROP COLUMN IF EXISTS version;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS version;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
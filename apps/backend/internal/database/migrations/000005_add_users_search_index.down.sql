@@ -0,0 +1,2 @@
+-- pg_trgm is left installed, other objects may depend on it
+DROP INDEX IF EXISTS idx_users_search;
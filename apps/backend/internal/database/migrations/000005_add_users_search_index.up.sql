@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The indexed expression must match the search expression used by the users repository
+CREATE INDEX IF NOT EXISTS idx_users_search ON users
+    USING GIN ((username || ' ' || COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) gin_trgm_ops);
@@ -0,0 +1,2 @@
+-- Supports keyset pagination on (created_at, id) in both directions
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
@@ -0,0 +1,5 @@
+-- Fails if a soft-deleted user shares its username with another user; purge
+-- or rename those rows before migrating down.
+DROP INDEX IF EXISTS idx_users_username;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_username;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username) WHERE deleted_at IS NULL;
@@ -0,0 +1,12 @@
+package dto
+
+// LoginRequest is the body accepted by POST /api/auth/login
+type LoginRequest struct {
+	Username string `json:"username" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// RefreshTokenRequest is the body accepted by POST /api/auth/refresh and /api/auth/logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+)
+
+// CreateUserRequest is the body accepted by POST /api/users
+type CreateUserRequest struct {
+	Username  string `json:"username" validate:"required,min=3,max=50,username"`
+	Password  string `json:"password" validate:"required,max=72,password"`
+	FirstName string `json:"first_name" validate:"max=100"`
+	LastName  string `json:"last_name" validate:"max=100"`
+}
+
+// UpdateUserRequest is the full representation accepted by PUT /api/users/:id.
+// Every field except the passwords must be present; the password is left
+// unchanged when omitted, and users changing their own password must also send
+// their current one. PATCH requests are merged onto the current user and
+// validated as an UpdateUserRequest.
+type UpdateUserRequest struct {
+	Username        *string `json:"username" validate:"required,min=3,max=50,username"`
+	Password        string  `json:"password,omitempty" validate:"omitempty,max=72,password"`
+	CurrentPassword string  `json:"current_password,omitempty" validate:"omitempty,max=72"`
+	FirstName       *string `json:"first_name" validate:"required,max=100"`
+	LastName        *string `json:"last_name" validate:"required,max=100"`
+	IsActive        *bool   `json:"is_active" validate:"required"`
+}
+
+// UserResponse is the public representation of a user
+type UserResponse struct {
+	ID        uint       `json:"id"`
+	Username  string     `json:"username"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	IsActive  bool       `json:"is_active"`
+	Roles     []string   `json:"roles"`
+	Version   uint       `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ToModel builds a new, active user from the request
+func (r *CreateUserRequest) ToModel() *models.User {
+	return &models.User{
+		Username:  r.Username,
+		Password:  r.Password,
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		IsActive:  true,
+	}
+}
+
+// NewUpdateUserRequest returns the editable representation of a user, which
+// is the document PATCH requests are merged onto
+func NewUpdateUserRequest(user *models.User) UpdateUserRequest {
+	return UpdateUserRequest{
+		Username:  &user.Username,
+		FirstName: &user.FirstName,
+		LastName:  &user.LastName,
+		IsActive:  &user.IsActive,
+	}
+}
+
+// ApplyTo copies the request fields onto an existing user. Call it only on a
+// validated request.
+func (r *UpdateUserRequest) ApplyTo(user *models.User) {
+	user.Username = *r.Username
+	user.FirstName = *r.FirstName
+	user.LastName = *r.LastName
+	user.IsActive = *r.IsActive
+	if r.Password != "" {
+		user.Password = r.Password
+	}
+}
+
+// NewUserResponse maps a user model to its public representation
+func NewUserResponse(user *models.User) UserResponse {
+	roles := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		roles = append(roles, role.Name)
+	}
+
+	response := UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		IsActive:  user.IsActive,
+		Roles:     roles,
+		Version:   user.Version,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+	if user.DeletedAt.Valid {
+		response.DeletedAt = &user.DeletedAt.Time
+	}
+	return response
+}
+
+// NewUserResponses maps a list of user models to their public representation
+func NewUserResponses(users []models.User) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, NewUserResponse(&users[i]))
+	}
+	return responses
+}
@@ -0,0 +1,33 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/database"
+)
+
+// Database checks that the database accepts connections
+func Database(db *sql.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// Migrations checks that every migration known to this build has been
+// applied. Migrations applied by a newer build are tolerated, so replicas of
+// the previous release stay ready during a rolling deployment.
+func Migrations(migrator *database.Migrator) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		unapplied, err := migrator.Unapplied(ctx)
+		if err != nil {
+			return err
+		}
+		if len(unapplied) > 0 {
+			return fmt.Errorf("%d pending migrations, starting with %d_%s",
+				len(unapplied), unapplied[0].Version, unapplied[0].Name)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,228 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/buildinfo"
+	"github.com/albuquerquewizard/monorepo/backend/internal/redact"
+)
+
+// Overall statuses reported by the probes
+const (
+	StatusHealthy      = "healthy"
+	StatusUnhealthy    = "unhealthy"
+	StatusStarting     = "starting"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Statuses of a single check
+const (
+	CheckPassed = "ok"
+	CheckFailed = "failed"
+)
+
+// Checker checks one dependency, such as the database, a cache or a queue.
+// Check must return promptly once ctx is done.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Status     string  `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the body served by the probes
+type Report struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	buildinfo.Info
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// check is a registered checker
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+}
+
+// Registry holds the dependency checks behind the readiness and startup
+// probes, and tracks whether the application has started and whether it is
+// shutting down
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry creates an empty registry whose checks time out after timeout
+// unless registered with their own
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register adds a check with the default timeout. Registering a name again
+// replaces the previous check.
+func (r *Registry) Register(name string, checker Checker) {
+	r.RegisterWithTimeout(name, 0, checker)
+}
+
+// RegisterWithTimeout adds a check with its own timeout; zero uses the default
+func (r *Registry) RegisterWithTimeout(name string, timeout time.Duration, checker Checker) {
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i] = check{name: name, checker: checker, timeout: timeout}
+			return
+		}
+	}
+	r.checks = append(r.checks, check{name: name, checker: checker, timeout: timeout})
+	sort.Slice(r.checks, func(i, j int) bool { return r.checks[i].name < r.checks[j].name })
+}
+
+// MarkStarted records that startup has completed, so the startup and
+// readiness probes start running the checks
+func (r *Registry) MarkStarted() {
+	r.started.Store(true)
+}
+
+// MarkShuttingDown makes the readiness probe fail from now on, so load
+// balancers stop routing new requests while in-flight ones drain
+func (r *Registry) MarkShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Started reports whether startup has completed
+func (r *Registry) Started() bool {
+	return r.started.Load()
+}
+
+// ShuttingDown reports whether shutdown has begun
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Liveness reports that the process is running. It runs no checks, since a
+// failing dependency is not fixed by restarting the process.
+func (r *Registry) Liveness() Report {
+	return newReport(StatusHealthy)
+}
+
+// Readiness reports whether the application should receive traffic: it has
+// started, is not shutting down and every check passes
+func (r *Registry) Readiness(ctx context.Context) Report {
+	switch {
+	case r.ShuttingDown():
+		return newReport(StatusShuttingDown)
+	case !r.Started():
+		return newReport(StatusStarting)
+	}
+	return r.Run(ctx)
+}
+
+// Startup reports whether the application has started and every check passes
+func (r *Registry) Startup(ctx context.Context) Report {
+	if !r.Started() {
+		return newReport(StatusStarting)
+	}
+	return r.Run(ctx)
+}
+
+// Run runs every check concurrently, each bounded by its timeout, and
+// reports healthy when all of them pass
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := newReport(StatusHealthy)
+	report.Checks = make(map[string]Result, len(checks))
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != CheckPassed {
+			report.Status = StatusUnhealthy
+		}
+	}
+	return report
+}
+
+// run runs the check, giving up when its timeout passes even if the checker
+// ignores its context. Error messages are redacted, since they may quote
+// connection strings.
+func (c check) run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", c.timeout)
+		}
+	}
+
+	result := Result{
+		Status:     CheckPassed,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = CheckFailed
+		result.Error = redact.String(err.Error())
+	}
+	return result
+}
+
+// newReport creates a report with the build metadata
+func newReport(status string) Report {
+	return Report{
+		Status:    status,
+		Timestamp: time.Now().UTC(),
+		Info:      buildinfo.Get(),
+	}
+}
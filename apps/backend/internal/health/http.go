@@ -0,0 +1,32 @@
+package health
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Livez handles GET /livez
+func (r *Registry) Livez(c *fiber.Ctx) error {
+	return respond(c, r.Liveness())
+}
+
+// Readyz handles GET /readyz
+func (r *Registry) Readyz(c *fiber.Ctx) error {
+	return respond(c, r.Readiness(c.UserContext()))
+}
+
+// Startupz handles GET /startupz
+func (r *Registry) Startupz(c *fiber.Ctx) error {
+	return respond(c, r.Startup(c.UserContext()))
+}
+
+// respond serves a report, with 503 Service Unavailable unless it is healthy
+func respond(c *fiber.Ctx, report Report) error {
+	status := fiber.StatusOK
+	if report.Status != StatusHealthy {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	// Probes must always reach the application, never a cached answer
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(report)
+}
@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/rs/zerolog"
+)
+
+// purgeTimeout bounds a single purge run
+const purgeTimeout = time.Minute
+
+// Purger periodically deletes users that were soft-deleted longer ago than
+// the configured retention period. Every replica may run it; purging is idempotent.
+type Purger struct {
+	users  services.UserService
+	cfg    config.PurgeConfig
+	logger zerolog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewPurger creates a purge job. It does nothing until Start is called.
+func NewPurger(users services.UserService, cfg config.PurgeConfig, logger zerolog.Logger) *Purger {
+	return &Purger{
+		users:  users,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Start runs a purge immediately and then every interval, in the background.
+// It does nothing when purging is disabled.
+func (p *Purger) Start() {
+	if p.cfg.AfterDays == 0 || p.cfg.Interval <= 0 {
+		p.logger.Info().Msg("🗑️ Purging of deleted users is disabled")
+		return
+	}
+
+	p.once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.cancel = cancel
+		p.done = make(chan struct{})
+
+		go p.run(ctx)
+	})
+}
+
+// Stop stops the job and waits for a running purge to finish
+func (p *Purger) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+// RunOnce permanently deletes users soft-deleted before the retention period
+func (p *Purger) RunOnce(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, purgeTimeout)
+	defer cancel()
+
+	return p.users.PurgeDeletedUsers(ctx, time.Now().Add(-p.cfg.Retention()))
+}
+
+// run purges on every tick until ctx is cancelled
+func (p *Purger) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.RunOnce(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			p.logger.Error().Err(err).Msg("Failed to purge deleted users")
+		case purged > 0:
+			p.logger.Info().Int64("purged", purged).Msgf("🗑️ Purged users deleted more than %d days ago", p.cfg.AfterDays)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Phase is a step of the shutdown sequence. Phases run in order, so that
+// nothing is closed while something running earlier may still use it.
+type Phase int
+
+// Shutdown phases, in the order they run
+const (
+	// PhaseNotReady makes the readiness probe fail so load balancers stop routing new requests
+	PhaseNotReady Phase = iota
+	// PhaseListeners stops accepting connections and drains in-flight requests
+	PhaseListeners
+	// PhaseWorkers stops background jobs
+	PhaseWorkers
+	// PhaseTelemetry flushes traces and stops the metrics listener
+	PhaseTelemetry
+	// PhaseResources closes the database and other connections
+	PhaseResources
+)
+
+var phaseNames = [...]string{"not ready", "listeners", "workers", "telemetry", "resources"}
+
+// String returns the phase name used in logs
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return fmt.Sprintf("phase %d", int(p))
+	}
+	return phaseNames[p]
+}
+
+// Hook stops one subsystem. It should return once ctx is done; the manager
+// stops waiting for it then either way.
+type Hook func(ctx context.Context) error
+
+// hook is a registered shutdown hook
+type hook struct {
+	phase Phase
+	name  string
+	fn    Hook
+}
+
+// Manager runs the shutdown hooks registered by the application's
+// subsystems, phase by phase
+type Manager struct {
+	logger zerolog.Logger
+
+	mu    sync.Mutex
+	hooks []hook
+
+	once sync.Once
+	err  error
+}
+
+// NewManager creates a manager with no hooks
+func NewManager(logger zerolog.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// OnShutdown registers a hook to run during the given phase. Hooks of the
+// same phase run one after another, in registration order.
+func (m *Manager) OnShutdown(phase Phase, name string, fn Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook{phase: phase, name: name, fn: fn})
+}
+
+// Shutdown runs every hook, phase by phase, within ctx's deadline. A failing
+// hook does not stop the sequence; all failures are returned together. Only
+// the first call runs the hooks, later ones return its result.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		m.mu.Lock()
+		hooks := append([]hook(nil), m.hooks...)
+		m.mu.Unlock()
+
+		var errs []error
+		for phase := PhaseNotReady; phase <= PhaseResources; phase++ {
+			for _, h := range hooks {
+				if h.phase != phase {
+					continue
+				}
+				if err := m.run(ctx, h); err != nil {
+					m.logger.Error().Err(err).Str("phase", phase.String()).Str("hook", h.name).Msg("Shutdown step failed")
+					errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+				}
+			}
+		}
+		m.err = errors.Join(errs...)
+	})
+	return m.err
+}
+
+// run runs a hook, giving up when ctx is done even if the hook ignores it
+func (m *Manager) run(ctx context.Context, h hook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.logger.Debug().Str("phase", h.phase.String()).Str("hook", h.name).Msg("Running shutdown step")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// unexplainedPlaceholder matches the $1$ markers GORM leaves in PostgreSQL
+// SQL when it is explained without its arguments
+var unexplainedPlaceholder = regexp.MustCompile(`\$(\d+)\$`)
+
+// GormLogger writes GORM's logs through zerolog. Every query is logged at
+// debug level, queries slower than the threshold at warn level and failed
+// queries at error level. Queries run with a request context are logged by
+// the request's logger, so they carry its request and trace IDs.
+type GormLogger struct {
+	logger        zerolog.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+	logValues     bool
+}
+
+// NewGormLogger creates a GORM logger. A zero slowThreshold disables slow
+// query warnings. Query arguments are only written when logValues is set,
+// since they may hold secrets such as password hashes and tokens.
+func NewGormLogger(logger zerolog.Logger, slowThreshold time.Duration, logValues bool) *GormLogger {
+	return &GormLogger{
+		logger:        logger,
+		level:         gormlogger.Info,
+		slowThreshold: slowThreshold,
+		logValues:     logValues,
+	}
+}
+
+// LogMode implements gormlogger.Interface
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info implements gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.loggerFor(ctx).Info().Ctx(ctx).Msgf(msg, args...)
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.loggerFor(ctx).Warn().Ctx(ctx).Msgf(msg, args...)
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.loggerFor(ctx).Error().Ctx(ctx).Msgf(msg, args...)
+	}
+}
+
+// Trace implements gormlogger.Interface by logging a finished query
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	logger := l.loggerFor(ctx)
+
+	var (
+		event *zerolog.Event
+		msg   string
+	)
+	switch {
+	// A missing record is an expected outcome, not a failed query
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		event, msg = logger.Error().Err(err), "Query failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		event, msg = logger.Warn().Dur("threshold", l.slowThreshold), "🐢 Slow query"
+	case l.level >= gormlogger.Info:
+		event, msg = logger.Debug(), "Query"
+	default:
+		return
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	if !l.logValues {
+		sql = unexplainedPlaceholder.ReplaceAllString(sql, "$$$1")
+	}
+	event = event.Ctx(ctx).Dur("elapsed", elapsed).Str("sql", sql)
+	if rows >= 0 {
+		event = event.Int64("rows", rows)
+	}
+	event.Msg(msg)
+}
+
+// ParamsFilter implements gorm.ParamsFilter, dropping query arguments from
+// logged SQL unless values are logged
+func (l *GormLogger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.logValues {
+		return sql, params
+	}
+	return sql, nil
+}
+
+// loggerFor returns the logger carried by ctx, such as a request's logger,
+// or the base logger
+func (l *GormLogger) loggerFor(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+			return logger
+		}
+	}
+	return &l.logger
+}
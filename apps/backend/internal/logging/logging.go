@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"io"
+	"os"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/redact"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats for config.LogConfig.Format
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Errors are redacted wherever they are logged, including by the global
+// logger before New runs
+func init() {
+	zerolog.ErrorMarshalFunc = redact.Error
+}
+
+// New builds the application logger: JSON lines for log collectors, or
+// human-readable console output. It sets the global level and replaces the
+// global zerolog logger, so packages logging before or without an injected
+// logger write through the same pipeline. redactFields extend the fields
+// redacted from logged errors, bodies and headers.
+func New(level, format string, redactFields []string) zerolog.Logger {
+	redact.SetDefault(redact.New(redactFields))
+
+	var out io.Writer = os.Stderr
+	if format == FormatConsole {
+		out = zerolog.ConsoleWriter{Out: os.Stderr}
+	}
+
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil || parsed == zerolog.NoLevel {
+		parsed = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(parsed)
+
+	logger := zerolog.New(out).With().Timestamp().Logger().Hook(TraceHook{})
+	log.Logger = logger
+	return logger
+}
+
+// TraceHook adds the trace and span IDs of an event's context to the log
+// line, so logs can be joined with traces. Events get the context with Ctx,
+// e.g. logger.Info().Ctx(ctx), or from a logger created with With().Ctx(ctx).
+type TraceHook struct{}
+
+// Run implements zerolog.Hook
+func (TraceHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	spanContext := trace.SpanContextFromContext(e.GetCtx())
+	if !spanContext.IsValid() {
+		return
+	}
+	e.Str("trace_id", spanContext.TraceID().String()).
+		Str("span_id", spanContext.SpanID().String())
+}
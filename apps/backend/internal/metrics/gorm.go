@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// queryStartKey stores the start time of a query on the GORM statement
+const queryStartKey = "metrics:query_start"
+
+// InstrumentDB reports the connection pool statistics of db (open, in use,
+// idle, wait count and duration) and the duration of every query it runs
+func (m *Metrics) InstrumentDB(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := m.registry.Register(collectors.NewDBStatsCollector(sqlDB, name)); err != nil {
+		return err
+	}
+
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("metrics:before_create", startQuery),
+		callback.Create().After("gorm:create").Register("metrics:after_create", m.observeQuery("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", startQuery),
+		callback.Query().After("gorm:query").Register("metrics:after_query", m.observeQuery("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", startQuery),
+		callback.Update().After("gorm:update").Register("metrics:after_update", m.observeQuery("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", startQuery),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", m.observeQuery("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", startQuery),
+		callback.Row().After("gorm:row").Register("metrics:after_row", m.observeQuery("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", startQuery),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", m.observeQuery("raw")),
+	)
+}
+
+// startQuery records when a query started
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+// observeQuery returns a callback recording the duration of a query
+func (m *Metrics) observeQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		m.queries.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware records request counts, latencies and in-flight requests. It
+// must be the first middleware so that it observes every request, and run
+// outside middleware.ErrorResponder so the recorded status is the one sent.
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		err := c.Next()
+
+		// Fiber strings point into reused buffers, so labels kept by Prometheus are copied
+		labels := []string{strings.Clone(c.Method()), utils.RouteTemplate(c), strconv.Itoa(c.Response().StatusCode())}
+		m.requests.WithLabelValues(labels...).Inc()
+		m.durations.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// AccessLog writes one structured log line per request with its route,
+// status, latency, sizes and the authenticated user, through the request's
+// logger so the line carries the request and trace IDs. Server errors are
+// logged at error level and client errors at warn level. It must run after
+// RequestID and outside ErrorResponder, so that the logged status is the
+// one sent.
+func AccessLog(logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		requestLogger := RequestLogger(c, logger)
+		event := requestLogger.Info()
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			event = requestLogger.Error()
+		case status >= fiber.StatusBadRequest:
+			event = requestLogger.Warn()
+		}
+		if !event.Enabled() {
+			return err
+		}
+
+		event = event.
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Str("route", utils.RouteTemplate(c)).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Int("bytes_in", len(c.Request().Body())).
+			Int("bytes_out", len(c.Response().Body())).
+			Str("ip", c.IP()).
+			Str("user_agent", c.Get(fiber.HeaderUserAgent))
+		if user, ok := CurrentUser(c); ok {
+			event = event.Uint("user_id", user.ID)
+		}
+		event.Msg("Request completed")
+		return err
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// currentUserKey is the fiber.Ctx Locals key holding the authenticated user
+const currentUserKey = "current_user"
+
+// Authentication errors returned by RequireAuth
+var (
+	ErrMissingToken = apperrors.Unauthorized("missing_token", "A bearer token is required")
+	ErrUserGone     = apperrors.Unauthorized("user_not_found", "The user for this token no longer exists")
+	ErrUserDisabled = apperrors.Unauthorized("user_inactive", "User account is deactivated")
+)
+
+// RequireAuth verifies the bearer token on the request, loads the user it was
+// issued to and stores it in the request context. Requests without a valid
+// token, or for users that no longer exist or are inactive, get an unauthorized error.
+func RequireAuth(authService services.AuthService, userService services.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := bearerToken(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			return unauthorized(c, ErrMissingToken)
+		}
+
+		userID, err := authService.VerifyAccessToken(token)
+		if err != nil {
+			return unauthorized(c, err)
+		}
+
+		user, err := userService.GetUserByID(c.UserContext(), userID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return unauthorized(c, ErrUserGone)
+			}
+			return err
+		}
+
+		if !user.IsActive {
+			return unauthorized(c, ErrUserDisabled)
+		}
+
+		c.Locals(currentUserKey, user)
+		return c.Next()
+	}
+}
+
+// CurrentUser returns the user authenticated by RequireAuth, if any
+func CurrentUser(c *fiber.Ctx) (*models.User, bool) {
+	user, ok := c.Locals(currentUserKey).(*models.User)
+	return user, ok && user != nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthorized challenges the client for a bearer token and returns err
+func unauthorized(c *fiber.Ctx, err error) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+	return err
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/services"
+	"github.com/albuquerquewizard/monorepo/backend/internal/testutil"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testJWT = config.JWTConfig{Secret: "test-secret", Issuer: "test", AccessTokenTTL: time.Minute}
+
+// newAuthApp creates an app whose routes require authentication with
+// access tokens issued for users, followed by handlers
+func newAuthApp(users *testutil.UserRepository, handlers ...fiber.Handler) *fiber.App {
+	auth := services.NewAuthService(nil, nil, nil, testJWT)
+	userService := services.NewUserService(users, nil, nil, nil, nil)
+
+	app := fiber.New(fiber.Config{ErrorHandler: GlobalErrorHandler})
+	routeHandlers := append([]fiber.Handler{RequireAuth(auth, userService)}, handlers...)
+	routeHandlers = append(routeHandlers, func(c *fiber.Ctx) error {
+		user, _ := CurrentUser(c)
+		return c.SendString(strconv.FormatUint(uint64(user.ID), 10))
+	})
+	app.Get("/users/:id", routeHandlers...)
+	return app
+}
+
+// signToken signs an access token for the user ID, like the auth service
+// but with the secret, issuer and expiry under test control
+func signToken(t *testing.T, secret, issuer string, userID uint, expiresAt time.Time) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Issuer:    issuer,
+		IssuedAt:  jwt.NewNumericDate(expiresAt.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func activeUser(id uint, permissions ...string) *models.User {
+	role := models.Role{Name: "role"}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, models.Permission{Name: permission})
+	}
+	return &models.User{
+		BaseModel: models.BaseModel{ID: id},
+		Username:  "user" + strconv.FormatUint(uint64(id), 10),
+		IsActive:  true,
+		Roles:     []models.Role{role},
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	inactive := activeUser(2)
+	inactive.IsActive = false
+	app := newAuthApp(testutil.NewUserRepository(activeUser(1), inactive))
+
+	valid := time.Now().Add(time.Minute)
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantCode      string
+	}{
+		{"valid token", "Bearer " + signToken(t, testJWT.Secret, testJWT.Issuer, 1, valid), fiber.StatusOK, ""},
+		{"lowercase scheme", "bearer " + signToken(t, testJWT.Secret, testJWT.Issuer, 1, valid), fiber.StatusOK, ""},
+		{"missing header", "", fiber.StatusUnauthorized, "missing_token"},
+		{"other scheme", "Basic dXNlcjpwYXNz", fiber.StatusUnauthorized, "missing_token"},
+		{"empty bearer token", "Bearer ", fiber.StatusUnauthorized, "missing_token"},
+		{"malformed token", "Bearer not-a-jwt", fiber.StatusUnauthorized, "invalid_access_token"},
+		{"expired token", "Bearer " + signToken(t, testJWT.Secret, testJWT.Issuer, 1, time.Now().Add(-time.Minute)),
+			fiber.StatusUnauthorized, "invalid_access_token"},
+		{"wrong issuer", "Bearer " + signToken(t, testJWT.Secret, "someone-else", 1, valid),
+			fiber.StatusUnauthorized, "invalid_access_token"},
+		{"wrong secret", "Bearer " + signToken(t, "other-secret", testJWT.Issuer, 1, valid),
+			fiber.StatusUnauthorized, "invalid_access_token"},
+		{"deleted user", "Bearer " + signToken(t, testJWT.Secret, testJWT.Issuer, 99, valid),
+			fiber.StatusUnauthorized, "user_not_found"},
+		{"inactive user", "Bearer " + signToken(t, testJWT.Secret, testJWT.Issuer, 2, valid),
+			fiber.StatusUnauthorized, "user_inactive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.authorization != "" {
+				headers[fiber.HeaderAuthorization] = tt.authorization
+			}
+
+			resp, body := testutil.Do(t, app, fiber.MethodGet, "/users/1", "", headers)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantStatus == fiber.StatusOK {
+				return
+			}
+			if !strings.Contains(body, `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %q", body, tt.wantCode)
+			}
+			if got := resp.Header.Get(fiber.HeaderWWWAuthenticate); !strings.HasPrefix(got, "Bearer") {
+				t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", got)
+			}
+		})
+	}
+}
@@ -2,118 +2,146 @@ package middleware
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"runtime/debug"
+	"strconv"
 	"strings"
 
-	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/redact"
+	"github.com/albuquerquewizard/monorepo/backend/internal/tracing"
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/rs/zerolog"
 )
 
-// Custom error types for better error handling
+// Custom error types for better error handling. These are the generic
+// domain errors, so any apperrors.Error of the same kind matches them.
 var (
-	ErrValidationFailed = errors.New("validation failed")
-	ErrNotFound         = errors.New("resource not found")
-	ErrUnauthorized     = errors.New("unauthorized")
-	ErrForbidden        = errors.New("forbidden")
-	ErrBadRequest       = errors.New("bad request")
-	ErrInternalServer   = errors.New("internal server error")
-	ErrTimeout          = errors.New("request timeout")
+	ErrValidationFailed = apperrors.ErrValidation
+	ErrNotFound         = apperrors.ErrNotFound
+	ErrUnauthorized     = apperrors.ErrUnauthorized
+	ErrForbidden        = apperrors.ErrForbidden
+	ErrBadRequest       = apperrors.ErrBadRequest
+	ErrInternalServer   = apperrors.ErrInternal
+	ErrTimeout          = apperrors.ErrTimeout
 )
 
 // ErrorResponse represents a detailed error response
 type ErrorResponse struct {
 	Success   bool                   `json:"success"`
 	Error     string                 `json:"error"`
+	Code      string                 `json:"code"`
 	Message   string                 `json:"message,omitempty"`
+	Fields    map[string]string      `json:"fields,omitempty"`
 	Details   map[string]interface{} `json:"details,omitempty"`
 	RequestID string                 `json:"request_id,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
 	Timestamp string                 `json:"timestamp,omitempty"`
 }
 
+// userMessages are the client-facing messages for domain errors that carry none
+var userMessages = map[apperrors.Kind]string{
+	apperrors.KindInternal:           "Something went wrong. Please try again later.",
+	apperrors.KindBadRequest:         "The request is malformed or contains invalid data.",
+	apperrors.KindValidation:         "The provided data is invalid. Please check your input.",
+	apperrors.KindUnauthorized:       "You are not authorized to access this resource.",
+	apperrors.KindForbidden:          "You do not have permission to access this resource.",
+	apperrors.KindNotFound:           "The requested resource was not found.",
+	apperrors.KindConflict:           "The request conflicts with the current state of the resource.",
+	apperrors.KindRateLimited:        "Too many requests. Please try again later.",
+	apperrors.KindTimeout:            "The request took too long to process.",
+	apperrors.KindPreconditionFailed: "The resource has changed since you last retrieved it.",
+}
+
 // GlobalErrorHandler is the main error handler for the application
 func GlobalErrorHandler(c *fiber.Ctx, err error) error {
-	// Get logger from context or create a new one
-	logger := zerolog.Nop()
-	if log := c.Locals("logger"); log != nil {
-		if l, ok := log.(zerolog.Logger); ok {
-			logger = l
-		}
-	}
+	// Get the request's logger, which carries the request ID
+	logger := RequestLogger(c, zerolog.Nop())
 
-	// Get request ID from context
-	requestID := c.Get("X-Request-ID")
-	if requestID == "" {
-		requestID = c.Get("X-Correlation-ID")
-	}
+	// Get request ID assigned by the RequestID middleware
+	requestID := GetRequestID(c)
 
 	// Default status code
 	statusCode := fiber.StatusInternalServerError
-	errorMessage := "Internal Server Error"
-	userMessage := "Something went wrong. Please try again later."
+	errorCode := apperrors.KindInternal.String()
+	userMessage := userMessages[apperrors.KindInternal]
+	var fields map[string]string
+
+	// Raw validator errors are translated into a validation error with field messages
+	var validationErrs validator.ValidationErrors
+	if _, ok := apperrors.As(err); !ok && errors.As(err, &validationErrs) {
+		err = validationError(err)
+	}
 
 	// Handle different types of errors
-	switch {
-	case errors.Is(err, ErrValidationFailed):
-		statusCode = fiber.StatusBadRequest
-		errorMessage = "Validation Failed"
-		userMessage = "The provided data is invalid. Please check your input."
-
-	case errors.Is(err, ErrNotFound):
-		statusCode = fiber.StatusNotFound
-		errorMessage = "Not Found"
-		userMessage = "The requested resource was not found."
-
-	case errors.Is(err, ErrUnauthorized):
-		statusCode = fiber.StatusUnauthorized
-		errorMessage = "Unauthorized"
-		userMessage = "You are not authorized to access this resource."
-
-	case errors.Is(err, ErrForbidden):
-		statusCode = fiber.StatusForbidden
-		errorMessage = "Forbidden"
-		userMessage = "You do not have permission to access this resource."
-
-	case errors.Is(err, ErrBadRequest):
-		statusCode = fiber.StatusBadRequest
-		errorMessage = "Bad Request"
-		userMessage = "The request is malformed or contains invalid data."
-
-	case errors.Is(err, ErrTimeout):
-		statusCode = fiber.StatusRequestTimeout
-		errorMessage = "Request Timeout"
-		userMessage = "The request took too long to process."
+	var fiberErr *fiber.Error
+	if appErr, ok := apperrors.As(err); ok {
+		statusCode = StatusForKind(appErr.Kind)
+		errorCode = appErr.Code
+		if errorCode == "" {
+			errorCode = appErr.Kind.String()
+		}
+		userMessage = appErr.Message
+		if userMessage == "" {
+			userMessage = userMessages[appErr.Kind]
+		}
+		fields = appErr.Fields
+		if fields == nil && errors.As(appErr, &validationErrs) {
+			fields = ValidationErrorFields(validationErrs)
+		}
 
-	default:
+		if appErr.Kind == apperrors.KindRateLimited && appErr.RetryAfter > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
+	} else if errors.As(err, &fiberErr) {
 		// Handle Fiber specific errors
-		if fiberErr, ok := err.(*fiber.Error); ok {
-			statusCode = fiberErr.Code
-			errorMessage = fiberErr.Message
-			userMessage = fiberErr.Message
+		statusCode = fiberErr.Code
+		errorCode = codeForStatus(fiberErr.Code)
+		userMessage = fiberErr.Message
+	}
+	errorMessage := utils.StatusMessage(statusCode)
+	development := isDevelopment(c)
+
+	// Server errors never expose internal error text to clients outside
+	// development, and even in development secrets are redacted from it
+	if statusCode >= fiber.StatusInternalServerError {
+		if development {
+			userMessage = redact.String(userMessage)
+		} else {
+			userMessage = userMessages[apperrors.KindInternal]
+			fields = nil
 		}
 	}
 
-	// Log the error with context
-	logger.Error().
+	// Log the error with context, client errors at a lower level. Server
+	// errors also log the request, with secrets redacted, to help reproduce them.
+	event := logger.Warn()
+	if statusCode >= fiber.StatusInternalServerError {
+		event = logger.Error().
+			Interface("headers", redact.Headers(c.GetReqHeaders())).
+			Str("body", redact.Body(c.Get(fiber.HeaderContentType), c.Body()))
+	}
+	event.
+		Ctx(c.UserContext()).
 		Err(err).
-		Str("request_id", requestID).
 		Str("method", c.Method()).
 		Str("path", c.Path()).
 		Str("ip", c.IP()).
 		Str("user_agent", c.Get("User-Agent")).
 		Int("status_code", statusCode).
 		Str("error_type", errorMessage).
+		Str("error_code", errorCode).
 		Msg("Request error occurred")
 
-	// In development mode, include stack trace
+	// In development mode, include stack trace and the redacted error
 	var details map[string]interface{}
-	// Check if we're in development mode by looking for environment variable or context
-	if env := c.Locals("env"); env != nil {
-		if envStr, ok := env.(string); ok && envStr == "development" {
-			details = map[string]interface{}{
-				"stack_trace": strings.Split(string(debug.Stack()), "\n"),
-				"error":       err.Error(),
-			}
+	if development {
+		details = map[string]interface{}{
+			"stack_trace": strings.Split(string(debug.Stack()), "\n"),
+			"error":       redact.String(err.Error()),
 		}
 	}
 
@@ -121,9 +149,12 @@ func GlobalErrorHandler(c *fiber.Ctx, err error) error {
 	errorResp := ErrorResponse{
 		Success:   false,
 		Error:     errorMessage,
+		Code:      errorCode,
 		Message:   userMessage,
+		Fields:    fields,
 		Details:   details,
 		RequestID: requestID,
+		TraceID:   tracing.TraceID(c.UserContext()),
 	}
 
 	// Add timestamp if available
@@ -137,49 +168,61 @@ func GlobalErrorHandler(c *fiber.Ctx, err error) error {
 	return c.Status(statusCode).JSON(errorResp)
 }
 
-// ValidationErrorHandler handles validation errors specifically
-func ValidationErrorHandler(c *fiber.Ctx, validationErrors map[string]string) error {
-	logger := zerolog.Nop()
-	if log := c.Locals("logger"); log != nil {
-		if l, ok := log.(zerolog.Logger); ok {
-			logger = l
-		}
-	}
+// isDevelopment reports whether the app runs in development, as recorded in
+// Locals("env"). Requests without it are treated as production.
+func isDevelopment(c *fiber.Ctx) bool {
+	env, ok := c.Locals("env").(string)
+	return ok && env == "development"
+}
 
-	requestID := c.Get("X-Request-ID")
-	if requestID == "" {
-		requestID = c.Get("X-Correlation-ID")
+// StatusForKind maps a domain error kind to its HTTP status code
+func StatusForKind(kind apperrors.Kind) int {
+	switch kind {
+	case apperrors.KindBadRequest:
+		return fiber.StatusBadRequest
+	case apperrors.KindValidation:
+		return fiber.StatusUnprocessableEntity
+	case apperrors.KindUnauthorized:
+		return fiber.StatusUnauthorized
+	case apperrors.KindForbidden:
+		return fiber.StatusForbidden
+	case apperrors.KindNotFound:
+		return fiber.StatusNotFound
+	case apperrors.KindConflict:
+		return fiber.StatusConflict
+	case apperrors.KindRateLimited:
+		return fiber.StatusTooManyRequests
+	case apperrors.KindTimeout:
+		return fiber.StatusRequestTimeout
+	case apperrors.KindPreconditionFailed:
+		return fiber.StatusPreconditionFailed
+	case apperrors.KindInternal:
+		return fiber.StatusInternalServerError
+	default:
+		return fiber.StatusInternalServerError
 	}
+}
 
-	// Log validation errors
-	logger.Warn().
-		Str("request_id", requestID).
-		Str("method", c.Method()).
-		Str("path", c.Path()).
-		Interface("validation_errors", validationErrors).
-		Msg("Validation failed")
+// codeForStatus derives a machine-readable error code from an HTTP status, e.g. "method_not_allowed"
+func codeForStatus(status int) string {
+	return strings.ToLower(strings.ReplaceAll(utils.StatusMessage(status), " ", "_"))
+}
 
-	// Use the existing utility function for consistency
-	return utils.ValidationErrorResponse(c, validationErrors)
+// ValidationErrorHandler responds with a validation error for the given
+// field messages, in the same shape as GlobalErrorHandler
+func ValidationErrorHandler(c *fiber.Ctx, validationErrors map[string]string) error {
+	return GlobalErrorHandler(c, apperrors.Validation(userMessages[apperrors.KindValidation], validationErrors))
 }
 
 // NotFoundHandler handles 404 errors
 func NotFoundHandler(c *fiber.Ctx) error {
-	logger := zerolog.Nop()
-	if log := c.Locals("logger"); log != nil {
-		if l, ok := log.(zerolog.Logger); ok {
-			logger = l
-		}
-	}
+	logger := RequestLogger(c, zerolog.Nop())
 
-	requestID := c.Get("X-Request-ID")
-	if requestID == "" {
-		requestID = c.Get("X-Correlation-ID")
-	}
+	requestID := GetRequestID(c)
 
 	// Log 404 errors
 	logger.Info().
-		Str("request_id", requestID).
+		Ctx(c.UserContext()).
 		Str("method", c.Method()).
 		Str("path", c.Path()).
 		Str("ip", c.IP()).
@@ -190,8 +233,10 @@ func NotFoundHandler(c *fiber.Ctx) error {
 	errorResp := ErrorResponse{
 		Success:   false,
 		Error:     "Not Found",
+		Code:      "route_not_found",
 		Message:   "The requested route was not found",
 		RequestID: requestID,
+		TraceID:   tracing.TraceID(c.UserContext()),
 	}
 
 	// Add timestamp if available
@@ -206,21 +251,13 @@ func NotFoundHandler(c *fiber.Ctx) error {
 
 // MethodNotAllowedHandler handles 405 errors
 func MethodNotAllowedHandler(c *fiber.Ctx) error {
-	logger := zerolog.Nop()
-	if log := c.Locals("logger"); log != nil {
-		if l, ok := log.(zerolog.Logger); ok {
-			logger = l
-		}
-	}
+	logger := RequestLogger(c, zerolog.Nop())
 
-	requestID := c.Get("X-Request-ID")
-	if requestID == "" {
-		requestID = c.Get("X-Correlation-ID")
-	}
+	requestID := GetRequestID(c)
 
 	// Log method not allowed errors
 	logger.Warn().
-		Str("request_id", requestID).
+		Ctx(c.UserContext()).
 		Str("method", c.Method()).
 		Str("path", c.Path()).
 		Str("ip", c.IP()).
@@ -231,8 +268,10 @@ func MethodNotAllowedHandler(c *fiber.Ctx) error {
 	errorResp := ErrorResponse{
 		Success:   false,
 		Error:     "Method Not Allowed",
+		Code:      codeForStatus(fiber.StatusMethodNotAllowed),
 		Message:   "The HTTP method is not allowed for this endpoint",
 		RequestID: requestID,
+		TraceID:   tracing.TraceID(c.UserContext()),
 	}
 
 	// Add timestamp if available
@@ -245,20 +284,39 @@ func MethodNotAllowedHandler(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusMethodNotAllowed).JSON(errorResp)
 }
 
+// ErrorResponder runs the app's error handler for errors returned by the
+// handlers it wraps and responds with a bare 500 if the error handler fails
+// too. It is the only middleware that handles errors: the metrics, tracing
+// and access log middleware run outside it and read the status it sent.
+func ErrorResponder() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err == nil {
+			return nil
+		}
+
+		tracing.RecordError(c.UserContext(), err)
+		if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+		return nil
+	}
+}
+
 // PanicRecoveryMiddleware recovers from panics and logs them
 func PanicRecoveryMiddleware(logger zerolog.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		defer func() {
 			if r := recover(); r != nil {
-				requestID := c.Get("X-Request-ID")
-				if requestID == "" {
-					requestID = c.Get("X-Correlation-ID")
-				}
+				requestID := GetRequestID(c)
 
 				// Log the panic
-				logger.Error().
-					Interface("panic", r).
-					Str("request_id", requestID).
+				requestLogger := RequestLogger(c, logger)
+				requestLogger.Error().
+					Ctx(c.UserContext()).
+					Str("panic", redact.String(fmt.Sprint(r))).
+					Interface("headers", redact.Headers(c.GetReqHeaders())).
+					Str("body", redact.Body(c.Get(fiber.HeaderContentType), c.Body())).
 					Str("method", c.Method()).
 					Str("path", c.Path()).
 					Str("stack_trace", string(debug.Stack())).
@@ -268,8 +326,10 @@ func PanicRecoveryMiddleware(logger zerolog.Logger) fiber.Handler {
 				errorResp := ErrorResponse{
 					Success:   false,
 					Error:     "Internal Server Error",
+					Code:      apperrors.KindInternal.String(),
 					Message:   "Something went wrong. Please try again later.",
 					RequestID: requestID,
+					TraceID:   tracing.TraceID(c.UserContext()),
 				}
 
 				// Add timestamp if available
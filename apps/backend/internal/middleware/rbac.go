@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrMissingPermission is returned when the authenticated user lacks a required permission
+var ErrMissingPermission = apperrors.Forbidden("missing_permission", "You do not have permission to access this resource.")
+
+// RequirePermission allows the request only if the authenticated user holds
+// every given permission. It must be mounted after RequireAuth.
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := CurrentUser(c)
+		if !ok {
+			return ErrUnauthorized
+		}
+
+		for _, permission := range permissions {
+			if !user.HasPermission(permission) {
+				return missingPermission(permission)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireSelfOrPermission allows the request if the route parameter param is
+// the authenticated user's own ID, or if the user holds the given permission.
+// It must be mounted after RequireAuth.
+func RequireSelfOrPermission(param, permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := CurrentUser(c)
+		if !ok {
+			return ErrUnauthorized
+		}
+
+		if id, err := strconv.ParseUint(c.Params(param), 10, 32); err == nil && uint(id) == user.ID {
+			return c.Next()
+		}
+
+		if !user.HasPermission(permission) {
+			return missingPermission(permission)
+		}
+
+		return c.Next()
+	}
+}
+
+// missingPermission returns ErrMissingPermission with the permission recorded for logs
+func missingPermission(permission string) error {
+	return ErrMissingPermission.Wrap(errors.New("missing permission " + permission))
+}
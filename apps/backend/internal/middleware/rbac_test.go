@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/testutil"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequirePermission(t *testing.T) {
+	users := testutil.NewUserRepository(
+		activeUser(1, models.PermissionUsersRestore, models.PermissionUsersPurge),
+		activeUser(2, models.PermissionUsersRestore),
+		activeUser(3),
+	)
+	app := newAuthApp(users, RequirePermission(models.PermissionUsersRestore, models.PermissionUsersPurge))
+
+	tests := []struct {
+		name       string
+		userID     uint
+		wantStatus int
+	}{
+		{"all permissions", 1, fiber.StatusOK},
+		{"one permission missing", 2, fiber.StatusForbidden},
+		{"no permissions", 3, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := requestAs(t, app, tt.userID, "/users/1")
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantStatus == fiber.StatusForbidden && !strings.Contains(body, `"code":"missing_permission"`) {
+				t.Errorf("body = %s, want code missing_permission", body)
+			}
+		})
+	}
+}
+
+func TestRequireSelfOrPermission(t *testing.T) {
+	users := testutil.NewUserRepository(
+		activeUser(1, models.PermissionUsersRead),
+		activeUser(2),
+	)
+	app := newAuthApp(users, RequireSelfOrPermission("id", models.PermissionUsersRead))
+
+	tests := []struct {
+		name       string
+		userID     uint
+		target     string
+		wantStatus int
+	}{
+		{"self", 2, "/users/2", fiber.StatusOK},
+		{"other user", 2, "/users/1", fiber.StatusForbidden},
+		{"other user with permission", 1, "/users/2", fiber.StatusOK},
+		{"non-numeric ID", 2, "/users/2abc", fiber.StatusForbidden},
+		{"non-numeric ID with permission", 1, "/users/me", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := requestAs(t, app, tt.userID, tt.target)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+		})
+	}
+}
+
+func TestPermissionMiddlewareRequiresAuth(t *testing.T) {
+	for name, handler := range map[string]fiber.Handler{
+		"RequirePermission":       RequirePermission(models.PermissionUsersList),
+		"RequireSelfOrPermission": RequireSelfOrPermission("id", models.PermissionUsersRead),
+	} {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: GlobalErrorHandler})
+			app.Get("/users/:id", handler, func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			if resp, body := testutil.Do(t, app, fiber.MethodGet, "/users/1", "", nil); resp.StatusCode != fiber.StatusUnauthorized {
+				t.Errorf("status = %d, want %d: %s", resp.StatusCode, fiber.StatusUnauthorized, body)
+			}
+		})
+	}
+}
+
+// requestAs sends a GET request to target with an access token for the user ID
+func requestAs(t *testing.T, app *fiber.App, userID uint, target string) (*http.Response, string) {
+	t.Helper()
+
+	token := signToken(t, testJWT.Secret, testJWT.Issuer, userID, time.Now().Add(time.Minute))
+	return testutil.Do(t, app, fiber.MethodGet, target, "", map[string]string{
+		fiber.HeaderAuthorization: "Bearer " + token,
+	})
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Request ID headers. Clients may send either; responses always carry RequestIDHeader.
+const (
+	RequestIDHeader     = "X-Request-ID"
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// Locals keys set by RequestID
+const (
+	requestIDKey = "request_id"
+	loggerKey    = "logger"
+)
+
+// requestIDPattern accepts UUIDs, ULIDs and similar opaque tokens, rejecting
+// anything that could break log lines or headers
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+// requestIDContextKey is the context.Context key holding the request ID
+type requestIDContextKey struct{}
+
+// RequestID identifies every request. It reuses a valid X-Request-ID or
+// X-Correlation-ID sent by the client and generates a UUID otherwise, echoes
+// the ID in the X-Request-ID response header, and stores it in Locals and in
+// the user context. It also stores a logger carrying the request ID and the
+// user context, so log lines include the trace ID, in Locals("logger") and
+// the user context; handlers get it with RequestLogger or zerolog.Ctx. It
+// must run after the tracing middleware and before everything else.
+func RequestID(logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if id == "" {
+			id = c.Get(CorrelationIDHeader)
+		}
+		if requestIDPattern.MatchString(id) {
+			// Fiber strings point into reused buffers, and the ID outlives the request in logs
+			id = strings.Clone(id)
+		} else {
+			id = uuid.NewString()
+		}
+
+		c.Set(RequestIDHeader, id)
+		c.Locals(requestIDKey, id)
+
+		ctx := context.WithValue(c.UserContext(), requestIDContextKey{}, id)
+		requestLogger := logger.With().Str("request_id", id).Ctx(ctx).Logger()
+		c.Locals(loggerKey, requestLogger)
+		c.SetUserContext(requestLogger.WithContext(ctx))
+
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned by RequestID, falling back to the
+// request headers when the middleware did not run
+func GetRequestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(requestIDKey).(string); ok {
+		return id
+	}
+	if id := c.Get(RequestIDHeader); id != "" {
+		return id
+	}
+	return c.Get(CorrelationIDHeader)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or "" outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// RequestLogger returns the per-request logger stored by RequestID, or
+// fallback when the middleware did not run
+func RequestLogger(c *fiber.Ctx, fallback zerolog.Logger) zerolog.Logger {
+	if logger, ok := c.Locals(loggerKey).(zerolog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
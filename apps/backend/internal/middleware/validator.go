@@ -1,7 +1,167 @@
 package middleware
 
 import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
 )
 
-var Validate = validator.New()
+// minPasswordLength is the shortest password accepted by the "password" rule
+const minPasswordLength = 8
+
+// usernamePattern is the character set accepted by the "username" rule
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Validate is the shared validator. It reports JSON field names and knows the
+// custom "username" and "password" rules.
+var Validate, translator = newValidator()
+
+// customRules are the validation rules registered on Validate, with their English messages
+var customRules = []struct {
+	tag     string
+	fn      validator.Func
+	message string
+}{
+	{
+		tag:     "username",
+		fn:      validateUsername,
+		message: "{0} may only contain letters, numbers, dots, dashes and underscores, and must start with a letter or number",
+	},
+	{
+		tag:     "password",
+		fn:      validatePassword,
+		message: "{0} must be at least 8 characters and contain an uppercase letter, a lowercase letter and a number",
+	},
+}
+
+// newValidator builds the validator and its English translator
+func newValidator() (*validator.Validate, ut.Translator) {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by the name clients send, not the Go field name
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	english := en.New()
+	trans, _ := ut.New(english, english).GetTranslator("en")
+	if err := entranslations.RegisterDefaultTranslations(v, trans); err != nil {
+		panic(err)
+	}
+
+	for _, rule := range customRules {
+		if err := v.RegisterValidation(rule.tag, rule.fn); err != nil {
+			panic(err)
+		}
+		if err := v.RegisterTranslation(rule.tag, trans, registerMessage(rule.tag, rule.message), translateField); err != nil {
+			panic(err)
+		}
+	}
+
+	return v, trans
+}
+
+// ValidateStruct validates s and returns an apperrors validation error with
+// one message per invalid field, or nil if s is valid
+func ValidateStruct(s interface{}) error {
+	err := Validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	return validationError(err)
+}
+
+// ValidationErrorFields translates validator errors into a map of JSON field
+// path to human-readable message
+func ValidationErrorFields(errs validator.ValidationErrors) map[string]string {
+	fields := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		field := fieldPath(fe)
+		if _, exists := fields[field]; exists {
+			continue
+		}
+
+		message := fe.Translate(translator)
+		// Untranslated tags fall back to the raw Go error text, which is not client friendly
+		if message == fe.Error() {
+			message = field + " is invalid"
+		}
+		fields[field] = message
+	}
+	return fields
+}
+
+// validationError converts a validator error into an apperrors validation error
+func validationError(err error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return apperrors.BadRequest("invalid_input", "The provided data could not be validated").Wrap(err)
+	}
+	return apperrors.Validation("The provided data is invalid. Please check your input.", ValidationErrorFields(errs)).Wrap(err)
+}
+
+// fieldPath returns the JSON path of the field, without the top-level struct name
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if _, path, found := strings.Cut(namespace, "."); found && path != "" {
+		return path
+	}
+	return fe.Field()
+}
+
+// registerMessage registers an English message for a custom rule
+func registerMessage(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+// translateField renders a registered message for the failing field
+func translateField(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
+
+// validateUsername checks the "username" rule
+func validateUsername(fl validator.FieldLevel) bool {
+	return usernamePattern.MatchString(fl.Field().String())
+}
+
+// validatePassword checks the "password" strength rule
+func validatePassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len(password) < minPasswordLength {
+		return false
+	}
+
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasUpper && hasLower && hasDigit
+}
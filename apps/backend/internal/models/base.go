@@ -6,12 +6,14 @@ import (
 	"gorm.io/gorm"
 )
 
-// BaseModel provides common fields for all models
+// BaseModel provides common fields for all models. Version is incremented on
+// every conditional update and is used for optimistic concurrency control.
 type BaseModel struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Version   uint           `json:"version" gorm:"not null;default:1"`
 }
 
 // BeforeCreate is a GORM hook that runs before creating a record
@@ -19,6 +21,9 @@ func (b *BaseModel) BeforeCreate(tx *gorm.DB) error {
 	now := time.Now()
 	b.CreatedAt = now
 	b.UpdatedAt = now
+	if b.Version == 0 {
+		b.Version = 1
+	}
 	return nil
 }
 
@@ -27,3 +32,14 @@ func (b *BaseModel) BeforeUpdate(tx *gorm.DB) error {
 	b.UpdatedAt = time.Now()
 	return nil
 }
+
+// Base returns the common fields, giving generic code access to them through
+// any model that embeds BaseModel
+func (b *BaseModel) Base() *BaseModel {
+	return b
+}
+
+// Position returns the keyset used for cursor pagination
+func (b BaseModel) Position() (time.Time, uint) {
+	return b.CreatedAt, b.ID
+}
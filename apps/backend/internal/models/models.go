@@ -1,16 +1,17 @@
 package models
 
 import (
-	"log"
-
-	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 )
 
 // Models returns all models for auto migration
 func Models() []interface{} {
 	return []interface{}{
+		&Permission{},
+		&Role{},
 		&User{},
+		&RefreshToken{},
 		// Add more models here as you create them for different practice projects:
 		// &Product{},
 		// &Order{},
@@ -20,32 +21,62 @@ func Models() []interface{} {
 	}
 }
 
-// AutoMigrate runs database migrations for all models
-func AutoMigrate(db *gorm.DB) error {
-	log.Println("🔄 Running database migrations...")
+// AutoMigrate runs GORM auto migration for all models. The schema is owned by
+// the versioned migrations in internal/database; this is a development aid only.
+func AutoMigrate(db *gorm.DB, logger zerolog.Logger) error {
+	logger.Info().Msg("🔄 Running database migrations...")
 
 	if err := db.AutoMigrate(Models()...); err != nil {
 		return err
 	}
 
-	log.Println("✅ Database migrations completed successfully")
+	logger.Info().Msg("✅ Database migrations completed successfully")
 	return nil
 }
 
 // SeedData seeds the database with initial data
-func SeedData(db *gorm.DB) error {
-	log.Println("🌱 Seeding database with initial data...")
+func SeedData(db *gorm.DB, logger zerolog.Logger) error {
+	logger.Info().Msg("🌱 Seeding database with initial data...")
 
-	// Load configuration to get app information
-	cfg := config.LoadConfig()
-	log.Printf("📋 App: %s (%s)", cfg.App.Name, cfg.App.Env)
+	if err := seedRoles(db, logger); err != nil {
+		return err
+	}
 
-	// No initial data needed for practice projects
 	// You can add sample data here for different project types:
 	// - E-commerce: sample products, categories
 	// - Blog: sample posts, users
 	// - Task manager: sample tasks, projects
 
-	log.Println("✅ Database seeding completed")
+	logger.Info().Msg("✅ Database seeding completed")
+	return nil
+}
+
+// seedRoles creates the default permissions and an admin role that holds
+// every permission, including those added by resource migrations
+func seedRoles(db *gorm.DB, logger zerolog.Logger) error {
+	for _, p := range DefaultPermissions() {
+		permission := Permission{}
+		if err := db.Where(Permission{Name: p.Name}).Attrs(p).FirstOrCreate(&permission).Error; err != nil {
+			return err
+		}
+	}
+
+	var permissions []Permission
+	if err := db.Find(&permissions).Error; err != nil {
+		return err
+	}
+
+	admin := Role{}
+	if err := db.Where(Role{Name: RoleAdmin}).
+		Attrs(Role{Description: "Full access to all resources"}).
+		FirstOrCreate(&admin).Error; err != nil {
+		return err
+	}
+
+	if err := db.Model(&admin).Association("Permissions").Replace(permissions); err != nil {
+		return err
+	}
+
+	logger.Info().Msgf("🔐 Seeded %q role with %d permissions", RoleAdmin, len(permissions))
 	return nil
 }
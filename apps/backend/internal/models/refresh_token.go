@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// RefreshToken represents a rotating refresh token issued to a user.
+// Only a SHA-256 hash of the token is stored. Every token issued from the
+// same login shares a FamilyID, so that reuse of an already rotated token
+// can revoke the whole family.
+type RefreshToken struct {
+	BaseModel
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	FamilyID  string     `json:"family_id" gorm:"not null;index;size:36"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// TableName specifies the table name for RefreshToken model
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// IsExpired reports whether the token has passed its expiry time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsRevoked reports whether the token has been rotated or revoked
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
@@ -0,0 +1,62 @@
+package models
+
+// Built-in role names
+const (
+	RoleAdmin = "admin"
+)
+
+// Built-in permission names, in "<resource>:<action>" form
+const (
+	PermissionUsersList    = "users:list"
+	PermissionUsersRead    = "users:read"
+	PermissionUsersUpdate  = "users:update"
+	PermissionUsersDelete  = "users:delete"
+	PermissionUsersRestore = "users:restore"
+	PermissionUsersPurge   = "users:purge"
+)
+
+// ResourcePermissions names the permission each action on a CRUD resource
+// requires. Listing requires Read; empty permissions are not checked.
+type ResourcePermissions struct {
+	Create string
+	Read   string
+	Update string
+	Delete string
+}
+
+// Permission represents a single action that can be granted through a role
+type Permission struct {
+	BaseModel
+	Name        string `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	Description string `json:"description" gorm:"size:255"`
+}
+
+// TableName specifies the table name for Permission model
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// Role groups permissions that can be assigned to users
+type Role struct {
+	BaseModel
+	Name        string       `json:"name" gorm:"uniqueIndex;not null;size:50"`
+	Description string       `json:"description" gorm:"size:255"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions"`
+}
+
+// TableName specifies the table name for Role model
+func (Role) TableName() string {
+	return "roles"
+}
+
+// DefaultPermissions returns the permissions every installation starts with
+func DefaultPermissions() []Permission {
+	return []Permission{
+		{Name: PermissionUsersList, Description: "List all users"},
+		{Name: PermissionUsersRead, Description: "View any user"},
+		{Name: PermissionUsersUpdate, Description: "Update any user"},
+		{Name: PermissionUsersDelete, Description: "Delete any user"},
+		{Name: PermissionUsersRestore, Description: "Restore deleted users"},
+		{Name: PermissionUsersPurge, Description: "Permanently delete users"},
+	}
+}
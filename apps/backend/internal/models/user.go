@@ -1,16 +1,41 @@
 package models
 
-// User represents a user in the system
+// User represents a user in the system. Usernames are unique among users that
+// are not soft-deleted, so a deleted user's username can be registered again.
 type User struct {
 	BaseModel
-	Username  string `json:"username" gorm:"uniqueIndex;not null;size:100"`
+	Username  string `json:"username" gorm:"uniqueIndex:idx_users_username,where:deleted_at IS NULL;not null;size:100" validate:"required,min=3,max=50,username"`
 	Password  string `json:"-" gorm:"not null;size:255"` // "-" means this field won't be included in JSON
-	FirstName string `json:"first_name" gorm:"size:100"`
-	LastName  string `json:"last_name" gorm:"size:100"`
+	FirstName string `json:"first_name" gorm:"size:100" validate:"max=100"`
+	LastName  string `json:"last_name" gorm:"size:100" validate:"max=100"`
 	IsActive  bool   `json:"is_active" gorm:"default:true"`
+	Roles     []Role `json:"roles,omitempty" gorm:"many2many:user_roles"`
 }
 
 // TableName specifies the table name for User model
 func (User) TableName() string {
 	return "users"
 }
+
+// HasRole reports whether the user has been assigned the named role
+func (u *User) HasRole(name string) bool {
+	for _, role := range u.Roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether any of the user's roles grants the named permission.
+// Roles and their permissions must be preloaded.
+func (u *User) HasPermission(name string) bool {
+	for _, role := range u.Roles {
+		for _, permission := range role.Permissions {
+			if permission.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed, tampered with
+// or were issued for a different sort order
+var ErrInvalidCursor = apperrors.BadRequest("invalid_cursor", "The pagination cursor is invalid")
+
+// Cursor is a position in a list ordered by (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"i"`
+	// Desc records the sort direction the cursor was issued for
+	Desc bool `json:"d,omitempty"`
+}
+
+// Codec encodes cursors as opaque, signed strings so clients cannot forge or
+// tamper with positions
+type Codec struct {
+	key []byte
+}
+
+// NewCodec creates a cursor codec signing with secret
+func NewCodec(secret string) *Codec {
+	return &Codec{key: []byte(secret)}
+}
+
+// Encode returns the opaque token for a cursor
+func (c *Codec) Encode(cursor Cursor) string {
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded))
+}
+
+// Decode verifies and parses a token produced by Encode
+func (c *Codec) Decode(token string) (Cursor, error) {
+	var cursor Cursor
+
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return cursor, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(encoded)) {
+		return cursor, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// sign returns the HMAC of an encoded cursor payload
+func (c *Codec) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte("cursor:" + encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,81 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	codec := NewCodec("secret")
+	cursor := Cursor{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC), ID: 42, Desc: true}
+
+	decoded, err := codec.Decode(codec.Encode(cursor))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID || decoded.Desc != cursor.Desc {
+		t.Errorf("Decode() = %+v, want %+v", decoded, cursor)
+	}
+}
+
+func TestCodecRejectsInvalidCursors(t *testing.T) {
+	codec := NewCodec("secret")
+	token := codec.Encode(Cursor{CreatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), ID: 42})
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// forged moves the cursor to another ID, keeping the original signature
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2024-05-01T00:00:00Z","i":1}`)) + "." + signature
+	// flipped changes one bit of the signature
+	mac, _ := base64.RawURLEncoding.DecodeString(signature)
+	mac[0] ^= 1
+	flipped := payload + "." + base64.RawURLEncoding.EncodeToString(mac)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"empty signature", payload + "."},
+		{"tampered payload", forged},
+		{"tampered signature", flipped},
+		{"signature not base64", payload + ".!!!"},
+		{"payload not base64", "!!!." + signature},
+		{"signed with another key", NewCodec("other-secret").Encode(Cursor{ID: 42})},
+		{"signed garbage payload", "bm90LWpzb24." + base64.RawURLEncoding.EncodeToString(codec.sign("bm90LWpzb24"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := codec.Decode(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Decode(%q) error = %v, want %v", tt.token, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestKeysetRejectsCursorForOtherDirection(t *testing.T) {
+	codec := NewCodec("secret")
+	ascending := codec.Encode(Cursor{CreatedAt: time.Now(), ID: 1})
+
+	tests := []struct {
+		name   string
+		params Params
+		desc   bool
+		want   error
+	}{
+		{"after, same direction", Params{Limit: 10, After: ascending}, false, nil},
+		{"after, other direction", Params{Limit: 10, After: ascending}, true, ErrInvalidCursor},
+		{"before, other direction", Params{Limit: 10, Before: ascending}, true, ErrInvalidCursor},
+		{"after, other key", Params{Limit: 10, After: NewCodec("other").Encode(Cursor{ID: 1})}, false, ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := codec.Keyset(&tt.params, tt.desc); !errors.Is(err, tt.want) {
+				t.Errorf("Keyset() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package pagination
+
+import (
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Keyset columns. Every paginated table has them through models.BaseModel.
+const (
+	timeColumn = "created_at"
+	idColumn   = "id"
+)
+
+// CountMode selects how the total of a keyset page is computed
+type CountMode string
+
+// Count modes. Exact counts can be slow on large tables, so the default is none.
+const (
+	CountNone      CountMode = "none"
+	CountExact     CountMode = "exact"
+	CountEstimated CountMode = "estimated"
+)
+
+// Positioned is implemented by pointers to models that can be keyset paginated
+type Positioned[T any] interface {
+	*T
+	Position() (createdAt time.Time, id uint)
+}
+
+// KeysetParams selects a keyset page. At most one of After and Before is set;
+// with neither the first page is returned.
+type KeysetParams struct {
+	After  *Cursor
+	Before *Cursor
+	Limit  int
+	Desc   bool
+	Count  CountMode
+}
+
+// Page is a page of a keyset paginated list. Next and Prev are nil at either end
+// of the list, and Total is nil unless a count was requested.
+type Page[T any] struct {
+	Items          []T
+	Next           *Cursor
+	Prev           *Cursor
+	Total          *int64
+	TotalEstimated bool
+}
+
+// Keyset fetches a page of T ordered by (created_at, id). The scopes, such as
+// filters, are applied to both the page query and the count.
+func Keyset[T any, PT Positioned[T]](db *gorm.DB, params KeysetParams, scopes ...func(*gorm.DB) *gorm.DB) (*Page[T], error) {
+	// db is used for more than one query, so conditions must not leak between them
+	db = db.Session(&gorm.Session{})
+
+	forward := params.Before == nil
+	cursor := params.After
+	if !forward {
+		cursor = params.Before
+	}
+
+	// Walking backwards queries in the opposite order, and the page is flipped back afterwards
+	desc := params.Desc == forward
+
+	query := db.Scopes(scopes...)
+	if cursor != nil {
+		operator := ">"
+		if desc {
+			operator = "<"
+		}
+		query = query.Where(clause.Expr{
+			SQL: "(?, ?) " + operator + " (?, ?)",
+			Vars: []interface{}{
+				clause.Column{Table: clause.CurrentTable, Name: timeColumn},
+				clause.Column{Table: clause.CurrentTable, Name: idColumn},
+				cursor.CreatedAt,
+				cursor.ID,
+			},
+		})
+	}
+
+	var items []T
+	err := query.
+		Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: timeColumn}, Desc: desc}).
+		Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: idColumn}, Desc: desc}).
+		Limit(params.Limit + 1).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// The extra row tells whether there is more beyond this page
+	hasMore := len(items) > params.Limit
+	if hasMore {
+		items = items[:params.Limit]
+	}
+	if !forward {
+		slices.Reverse(items)
+	}
+
+	page := &Page[T]{Items: items}
+	hasNext, hasPrev := hasMore, cursor != nil
+	if !forward {
+		hasNext, hasPrev = cursor != nil, hasMore
+	}
+	if len(items) > 0 {
+		if hasNext {
+			page.Next = position(PT(&items[len(items)-1]), params.Desc)
+		}
+		if hasPrev {
+			page.Prev = position(PT(&items[0]), params.Desc)
+		}
+	}
+
+	if params.Count != CountNone && params.Count != "" {
+		total, estimated, err := count[T](db, params.Count, scopes...)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+		page.TotalEstimated = estimated
+	}
+
+	return page, nil
+}
+
+// count returns the number of rows matching scopes. Estimated counts read the
+// planner statistics for the whole table and ignore the scopes, falling back
+// to an exact count when the table has not been analyzed yet.
+func count[T any](db *gorm.DB, mode CountMode, scopes ...func(*gorm.DB) *gorm.DB) (int64, bool, error) {
+	var total int64
+
+	// Start from a clean statement, without the preloads of the page query
+	db = db.Session(&gorm.Session{NewDB: true})
+
+	if mode == CountEstimated {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(new(T)); err != nil {
+			return 0, false, err
+		}
+
+		var estimate float64
+		err := db.Raw("SELECT reltuples FROM pg_class WHERE oid = to_regclass(?)", stmt.Schema.Table).Scan(&estimate).Error
+		if err != nil {
+			return 0, false, err
+		}
+		if estimate >= 0 {
+			return int64(estimate), true, nil
+		}
+	}
+
+	err := db.Model(new(T)).Scopes(scopes...).Count(&total).Error
+	return total, false, err
+}
+
+// position returns the cursor pointing at item
+func position(item interface{ Position() (time.Time, uint) }, desc bool) *Cursor {
+	createdAt, id := item.Position()
+	return &Cursor{CreatedAt: createdAt, ID: id, Desc: desc}
+}
@@ -0,0 +1,170 @@
+package pagination
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+)
+
+// Mode is the way a list is paginated
+type Mode string
+
+// Pagination modes. The mode is chosen by the query parameters: page, offset,
+// or after/before for cursors. Without any of them offset mode is used.
+const (
+	ModePage   Mode = "page"
+	ModeOffset Mode = "offset"
+	ModeCursor Mode = "cursor"
+)
+
+// Query parameter names understood by Parse
+const (
+	LimitParam  = "limit"
+	PageParam   = "page"
+	OffsetParam = "offset"
+	AfterParam  = "after"
+	BeforeParam = "before"
+	CountParam  = "count"
+)
+
+// Limit bounds
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+// MaxOffset bounds how many rows page and offset pagination may skip. Deep
+// offsets are slow to query, and unbounded ones overflow; lists that deep
+// should use cursor pagination.
+const MaxOffset = 10000
+
+// Params are validated pagination parameters
+type Params struct {
+	Mode   Mode
+	Limit  int
+	Page   int
+	Offset int
+	// After and Before are the raw cursor tokens; see Codec.Keyset
+	After  string
+	Before string
+	Count  CountMode
+}
+
+// Parse reads and validates the pagination query parameters. Invalid values
+// and mixed modes are validation errors rather than being silently clamped.
+func Parse(params map[string]string) (*Params, error) {
+	present := func(name string) bool {
+		_, ok := params[name]
+		return ok
+	}
+
+	p := &Params{Mode: ModeOffset, Limit: DefaultLimit, Count: CountNone}
+	fields := map[string]string{}
+
+	if present(LimitParam) {
+		limit, err := strconv.Atoi(params[LimitParam])
+		if err != nil || limit < 1 || limit > MaxLimit {
+			fields[LimitParam] = fmt.Sprintf("limit must be an integer between 1 and %d", MaxLimit)
+		}
+		p.Limit = limit
+	}
+
+	var modes []Mode
+	if present(PageParam) {
+		modes = append(modes, ModePage)
+		page, err := strconv.Atoi(params[PageParam])
+		if err != nil || page < 1 {
+			fields[PageParam] = "page must be an integer of at least 1"
+		}
+		p.Page = page
+	}
+	if present(OffsetParam) {
+		modes = append(modes, ModeOffset)
+		offset, err := strconv.Atoi(params[OffsetParam])
+		if err != nil || offset < 0 {
+			fields[OffsetParam] = "offset must be a non-negative integer"
+		}
+		p.Offset = offset
+	}
+	if present(AfterParam) || present(BeforeParam) {
+		modes = append(modes, ModeCursor)
+		p.After, p.Before = params[AfterParam], params[BeforeParam]
+		if p.After != "" && p.Before != "" {
+			fields[BeforeParam] = "after and before cannot be combined"
+		}
+	}
+
+	switch len(modes) {
+	case 0:
+	case 1:
+		p.Mode = modes[0]
+	default:
+		fields[string(modes[1])] = "page, offset and after/before cannot be combined"
+	}
+
+	if present(CountParam) {
+		p.Count = CountMode(params[CountParam])
+		switch p.Count {
+		case CountNone, CountExact, CountEstimated:
+			if p.Mode != ModeCursor {
+				fields[CountParam] = "count is only supported with cursor pagination"
+			}
+		default:
+			fields[CountParam] = "count must be one of none, exact or estimated"
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("Invalid pagination parameters", fields)
+	}
+
+	switch {
+	case p.Mode == ModePage && p.Page-1 > MaxOffset/p.Limit:
+		return nil, outOfRange(PageParam, "page", MaxOffset/p.Limit+1)
+	case p.Mode == ModeOffset && p.Offset > MaxOffset:
+		return nil, outOfRange(OffsetParam, "offset", MaxOffset)
+	}
+
+	if p.Mode == ModePage {
+		p.Offset = (p.Page - 1) * p.Limit
+	}
+	return p, nil
+}
+
+// outOfRange returns the error for a page or offset beyond MaxOffset
+func outOfRange(param, name string, max int) error {
+	err := apperrors.BadRequest("pagination_out_of_range",
+		"The requested page is too deep; use cursor pagination with after/before instead")
+	err.Fields = map[string]string{param: fmt.Sprintf("%s must be at most %d", name, max)}
+	return err
+}
+
+// Keyset decodes the cursors of cursor mode params into keyset parameters.
+// Cursors must have been issued for the same sort direction.
+func (c *Codec) Keyset(p *Params, desc bool) (KeysetParams, error) {
+	params := KeysetParams{Limit: p.Limit, Desc: desc, Count: p.Count}
+
+	var err error
+	if p.After != "" {
+		if params.After, err = c.decodeFor(p.After, desc); err != nil {
+			return params, err
+		}
+	}
+	if p.Before != "" {
+		if params.Before, err = c.decodeFor(p.Before, desc); err != nil {
+			return params, err
+		}
+	}
+
+	return params, nil
+}
+
+// decodeFor decodes a cursor token and checks it was issued for the sort direction
+func (c *Codec) decodeFor(token string, desc bool) (*Cursor, error) {
+	cursor, err := c.Decode(token)
+	if err != nil || cursor.Desc != desc {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,37 @@
+package pagination
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+)
+
+func TestParseBoundsOffset(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     map[string]string
+		wantOffset int
+		wantErr    error
+	}{
+		{"last page within bound", map[string]string{"page": "101", "limit": "100"}, MaxOffset, nil},
+		{"page past bound", map[string]string{"page": "102", "limit": "100"}, 0, apperrors.ErrBadRequest},
+		{"page that would overflow", map[string]string{"page": strconv.Itoa(math.MaxInt), "limit": "100"}, 0, apperrors.ErrBadRequest},
+		{"offset at bound", map[string]string{"offset": strconv.Itoa(MaxOffset)}, MaxOffset, nil},
+		{"offset past bound", map[string]string{"offset": strconv.Itoa(MaxOffset + 1)}, 0, apperrors.ErrBadRequest},
+		{"page not a number", map[string]string{"page": "1e9"}, 0, apperrors.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.params)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && p.Offset != tt.wantOffset {
+				t.Errorf("Parse() offset = %d, want %d", p.Offset, tt.wantOffset)
+			}
+		})
+	}
+}
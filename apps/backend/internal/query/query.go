@@ -0,0 +1,260 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Query parameter names understood by Parse
+const (
+	SearchParam  = "q"
+	SortParam    = "sort"
+	TrashedParam = "trashed"
+	filterPrefix = "filter["
+)
+
+// maxSearchLength is the longest accepted ?q= value
+const maxSearchLength = 100
+
+// FilterType is the type a filter value is parsed as
+type FilterType int
+
+// Filter value types
+const (
+	String FilterType = iota
+	Bool
+	Int
+)
+
+// Filter describes a filterable field
+type Filter struct {
+	Column string
+	Type   FilterType
+}
+
+// Trashed selects which soft-deleted rows a list includes
+type Trashed string
+
+// Values of ?trashed=. Without the parameter, soft-deleted rows are excluded.
+const (
+	TrashedNone Trashed = ""
+	TrashedWith Trashed = "with"
+	TrashedOnly Trashed = "only"
+)
+
+// Sort is a single sort key. A leading "-" in the query string sorts descending.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Schema whitelists the filters, sorts and search a resource supports, and
+// maps their public names to database columns
+type Schema struct {
+	// Filters maps filter[name] to a column
+	Filters map[string]Filter
+	// Sorts maps sortable field names to columns
+	Sorts map[string]string
+	// Search is the SQL expression matched by ?q=; empty disables search
+	Search string
+	// DefaultSort is used when the request has no ?sort=
+	DefaultSort []Sort
+	// TieBreaker is a unique column appended to every order so results are deterministic
+	TieBreaker string
+	// Trashed allows ?trashed=with|only to include soft-deleted rows
+	Trashed bool
+}
+
+// Spec is a parsed, validated list query
+type Spec struct {
+	schema  *Schema
+	filters map[string]interface{}
+	search  string
+	sorts   []Sort
+	trashed Trashed
+}
+
+// Parse reads filter[...], q, trashed and sort from the query parameters and validates
+// them against schema. Unknown filters and sort fields are validation errors.
+func Parse(params map[string]string, schema *Schema) (*Spec, error) {
+	spec := &Spec{schema: schema, filters: map[string]interface{}{}}
+	fields := map[string]string{}
+
+	for key, raw := range params {
+		name, ok := filterName(key)
+		if !ok {
+			continue
+		}
+
+		filter, known := schema.Filters[name]
+		if !known {
+			fields[key] = fmt.Sprintf("%s is not a supported filter", name)
+			continue
+		}
+
+		value, err := parseValue(raw, filter.Type)
+		if err != nil {
+			fields[key] = key + " " + err.Error()
+			continue
+		}
+		spec.filters[name] = value
+	}
+
+	if search := strings.TrimSpace(params[SearchParam]); search != "" {
+		switch {
+		case schema.Search == "":
+			fields[SearchParam] = "search is not supported"
+		case len(search) > maxSearchLength:
+			fields[SearchParam] = fmt.Sprintf("q must be at most %d characters", maxSearchLength)
+		default:
+			spec.search = search
+		}
+	}
+
+	if raw := params[TrashedParam]; raw != "" {
+		switch trashed := Trashed(raw); {
+		case !schema.Trashed:
+			fields[TrashedParam] = "trashed is not supported"
+		case trashed != TrashedWith && trashed != TrashedOnly:
+			fields[TrashedParam] = "trashed must be one of: with, only"
+		default:
+			spec.trashed = trashed
+		}
+	}
+
+	if raw := params[SortParam]; raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			sortKey := Sort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+			if _, ok := schema.Sorts[sortKey.Field]; !ok {
+				fields[SortParam] = fmt.Sprintf("cannot sort by %q", sortKey.Field)
+				break
+			}
+			spec.sorts = append(spec.sorts, sortKey)
+		}
+	} else {
+		spec.sorts = schema.DefaultSort
+	}
+
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("Invalid query parameters", fields)
+	}
+	return spec, nil
+}
+
+// Filter is a GORM scope applying the filters, search and trashed selection of the spec
+func (s *Spec) Filter(db *gorm.DB) *gorm.DB {
+	switch s.trashed {
+	case TrashedWith:
+		db = db.Unscoped()
+	case TrashedOnly:
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	for _, name := range s.filterNames() {
+		db = db.Where(clause.Eq{Column: clause.Column{Name: s.schema.Filters[name].Column}, Value: s.filters[name]})
+	}
+	if s.search != "" {
+		db = db.Where(s.schema.Search+" ILIKE ?", "%"+escapeLike(s.search)+"%")
+	}
+	return db
+}
+
+// Order is a GORM scope applying the sort of the spec
+func (s *Spec) Order(db *gorm.DB) *gorm.DB {
+	hasTieBreaker := false
+	for _, sortKey := range s.sorts {
+		column := s.schema.Sorts[sortKey.Field]
+		hasTieBreaker = hasTieBreaker || column == s.schema.TieBreaker
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: sortKey.Desc})
+	}
+	if s.schema.TieBreaker != "" && !hasTieBreaker {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: s.schema.TieBreaker}})
+	}
+	return db
+}
+
+// Meta describes the applied filters, search and sort, for echoing in responses
+func (s *Spec) Meta() map[string]interface{} {
+	meta := map[string]interface{}{
+		"filters": s.filters,
+		"sort":    s.SortString(),
+	}
+	if s.search != "" {
+		meta["q"] = s.search
+	}
+	if s.trashed != TrashedNone {
+		meta["trashed"] = s.trashed
+	}
+	return meta
+}
+
+// SingleSort returns the sort key if the spec sorts by exactly one field
+func (s *Spec) SingleSort() (Sort, bool) {
+	if len(s.sorts) != 1 {
+		return Sort{}, false
+	}
+	return s.sorts[0], true
+}
+
+// SortString renders the applied sort in ?sort= syntax
+func (s *Spec) SortString() string {
+	parts := make([]string, 0, len(s.sorts))
+	for _, sortKey := range s.sorts {
+		if sortKey.Desc {
+			parts = append(parts, "-"+sortKey.Field)
+		} else {
+			parts = append(parts, sortKey.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// filterNames returns the applied filter names in a stable order, so generated SQL is deterministic
+func (s *Spec) filterNames() []string {
+	names := make([]string, 0, len(s.filters))
+	for name := range s.filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// filterName extracts name from a filter[name] query key
+func filterName(key string) (string, bool) {
+	if !strings.HasPrefix(key, filterPrefix) || !strings.HasSuffix(key, "]") {
+		return "", false
+	}
+	return key[len(filterPrefix) : len(key)-1], true
+}
+
+// parseValue converts a raw filter value to the filter's type
+func parseValue(raw string, filterType FilterType) (interface{}, error) {
+	switch filterType {
+	case Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return value, nil
+	case Int:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return value, nil
+	default:
+		return raw, nil
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// APIKeyHeader identifies clients for policies keyed by API key
+const APIKeyHeader = "X-API-Key"
+
+// Rate limit response headers, as in the IETF RateLimit header fields draft
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+	HeaderPolicy    = "RateLimit-Policy"
+)
+
+// resultKey is the Locals key holding the most restrictive result reported so far
+const resultKey = "ratelimit_result"
+
+// Limiter applies the configured rate limit policies to routes
+type Limiter struct {
+	enabled  bool
+	policies map[string]*Policy
+	logger   zerolog.Logger
+}
+
+// NewLimiter creates a limiter for the configured policies, with state in store
+func NewLimiter(cfg config.RateLimitConfig, store Store, logger zerolog.Logger) *Limiter {
+	policies := make(map[string]*Policy, len(cfg.Policies))
+	for name, policy := range cfg.Policies {
+		policies[name] = NewPolicy(name, policy, store)
+	}
+	return &Limiter{enabled: cfg.Enabled, policies: policies, logger: logger}
+}
+
+// Middleware limits requests with the named policy. Requests over the limit
+// get 429 Too Many Requests with a Retry-After header, and every response
+// carries RateLimit-* headers for the most restrictive policy applied. When
+// the store fails, requests are let through rather than failing the API.
+// Policies keyed by user must be mounted after RequireAuth.
+func (l *Limiter) Middleware(name string) fiber.Handler {
+	policy, ok := l.policies[name]
+	if !ok {
+		l.logger.Warn().Str("policy", name).Msg("Unknown rate limit policy, requests are not limited")
+	}
+	if !l.enabled || !ok || policy.Config().Limit == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		result, err := policy.Allow(c.UserContext(), clientKey(c, policy.Config().Key), time.Now())
+		if err != nil {
+			logger := middleware.RequestLogger(c, l.logger)
+			logger.Warn().Err(err).Str("policy", name).Msg("Rate limit store failed, allowing request")
+			return c.Next()
+		}
+
+		setHeaders(c, policy, result)
+		if !result.Allowed {
+			return apperrors.RateLimited(result.RetryAfter)
+		}
+		return c.Next()
+	}
+}
+
+// clientKey identifies the client by the policy's key, falling back to the
+// client IP for anonymous requests and requests without an API key. Behind a
+// trusted proxy the IP comes from the configured proxy header.
+func clientKey(c *fiber.Ctx, key string) string {
+	switch key {
+	case config.RateLimitKeyUser:
+		if user, ok := middleware.CurrentUser(c); ok {
+			return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+		}
+	case config.RateLimitKeyAPIKey:
+		if apiKey := c.Get(APIKeyHeader); apiKey != "" {
+			// API keys are secrets, so only their hash is stored
+			sum := sha256.Sum256([]byte(apiKey))
+			return "api_key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + c.IP()
+}
+
+// setHeaders reports the result in RateLimit-* headers, unless a policy
+// applied earlier to the request is more restrictive
+func setHeaders(c *fiber.Ctx, policy *Policy, result Result) {
+	if reported, ok := c.Locals(resultKey).(Result); ok && result.Allowed && reported.Remaining <= result.Remaining {
+		return
+	}
+	c.Locals(resultKey, result)
+
+	c.Set(HeaderLimit, strconv.Itoa(result.Limit))
+	c.Set(HeaderRemaining, strconv.Itoa(result.Remaining))
+	c.Set(HeaderReset, strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+	c.Set(HeaderPolicy, policyHeader(policy.Config()))
+}
+
+// policyHeader describes a policy, e.g. "10;w=900" for 10 requests per 15 minutes
+func policyHeader(policy config.RateLimitPolicy) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(policy.Limit))
+	b.WriteString(";w=")
+	b.WriteString(strconv.Itoa(int(policy.Window.Seconds())))
+	if policy.Algorithm == config.RateLimitTokenBucket && policy.Burst > 0 {
+		b.WriteString(";burst=")
+		b.WriteString(strconv.Itoa(policy.Burst))
+	}
+	return b.String()
+}
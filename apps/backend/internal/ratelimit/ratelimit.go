@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+)
+
+// Result is the outcome of checking a request against a policy
+type Result struct {
+	Allowed bool
+	// Limit is how many requests the policy allows per window
+	Limit int
+	// Remaining is how many more requests are allowed right now
+	Remaining int
+	// Reset is how long until the full limit is available again
+	Reset time.Duration
+	// RetryAfter is how long until a denied request would be allowed
+	RetryAfter time.Duration
+}
+
+// Policy applies a configured limit to keys, with state in a store
+type Policy struct {
+	name   string
+	config config.RateLimitPolicy
+	store  Store
+}
+
+// NewPolicy creates a policy named name, which prefixes its keys in the store
+func NewPolicy(name string, cfg config.RateLimitPolicy, store Store) *Policy {
+	return &Policy{name: name, config: cfg, store: store}
+}
+
+// Name returns the policy name
+func (p *Policy) Name() string {
+	return p.name
+}
+
+// Config returns the policy configuration
+func (p *Policy) Config() config.RateLimitPolicy {
+	return p.config
+}
+
+// Allow counts a request by key against the policy
+func (p *Policy) Allow(ctx context.Context, key string, now time.Time) (Result, error) {
+	storeKey := "ratelimit:" + p.name + ":" + key
+	if p.config.Algorithm == config.RateLimitSlidingWindow {
+		window := Window{Limit: int64(p.config.Limit), Size: p.config.Window}
+		state, err := p.store.CountRequest(ctx, storeKey, window, now)
+		if err != nil {
+			return Result{}, err
+		}
+		return windowResult(window, state), nil
+	}
+
+	bucket := p.bucket()
+	state, err := p.store.TakeToken(ctx, storeKey, bucket, now)
+	if err != nil {
+		return Result{}, err
+	}
+	return bucketResult(bucket, state), nil
+}
+
+// bucket returns the token bucket for the policy: Limit tokens per Window,
+// up to Burst at once
+func (p *Policy) bucket() Bucket {
+	capacity := p.config.Burst
+	if capacity == 0 {
+		capacity = p.config.Limit
+	}
+	return Bucket{
+		Capacity: float64(capacity),
+		Rate:     float64(p.config.Limit) / p.config.Window.Seconds(),
+	}
+}
+
+// bucketResult describes a token bucket state. Its limit is the bucket
+// capacity, the most requests allowed at once.
+func bucketResult(bucket Bucket, state BucketState) Result {
+	result := Result{
+		Allowed:   state.Allowed,
+		Limit:     int(bucket.Capacity),
+		Remaining: int(math.Floor(state.Tokens)),
+		Reset:     seconds((bucket.Capacity - state.Tokens) / bucket.Rate),
+	}
+	if !state.Allowed {
+		result.RetryAfter = seconds((1 - state.Tokens) / bucket.Rate)
+	}
+	return result
+}
+
+// windowResult describes a sliding window state. The previous window's
+// weight shrinks as the current one elapses, which frees up requests.
+func windowResult(window Window, state WindowState) Result {
+	limit := float64(window.Limit)
+	previous, current := float64(state.Previous), float64(state.Current)
+	size := window.Size.Seconds()
+	untilNextWindow := (1 - state.Elapsed) * size
+
+	count := previous*(1-state.Elapsed) + current
+	result := Result{
+		Allowed:   state.Allowed,
+		Limit:     int(window.Limit),
+		Remaining: max(int(math.Ceil(limit-count)), 0),
+		// The previous window's requests stop counting when the current one
+		// ends, and the current window's one window later
+		Reset: seconds(untilNextWindow),
+	}
+	if current > 0 {
+		result.Reset = seconds(untilNextWindow + size)
+	}
+
+	if !state.Allowed {
+		if current < limit {
+			// Allowed once the previous window's weight drops below limit - current
+			result.RetryAfter = seconds((1 - (limit-current)/previous - state.Elapsed) * size)
+		} else {
+			// Allowed in the next window, once this window's weight drops below the limit
+			result.RetryAfter = seconds(untilNextWindow + (1-limit/current)*size)
+		}
+	}
+	return result
+}
+
+// seconds converts seconds to a duration, rounding negative values to zero
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
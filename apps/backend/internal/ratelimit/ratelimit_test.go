@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+)
+
+// start is aligned to the fixed windows of the sliding window policies
+var start = time.Unix(1_700_000_000, 0)
+
+// step is a request made at start+at, by key or "client"
+type step struct {
+	at   time.Duration
+	key  string
+	want Result
+}
+
+var policyTests = []struct {
+	name   string
+	policy config.RateLimitPolicy
+	steps  []step
+}{
+	{
+		name: "token bucket",
+		// 2 tokens per 10s, so one every 5s, up to 3 at once
+		policy: config.RateLimitPolicy{Algorithm: config.RateLimitTokenBucket, Limit: 2, Window: 10 * time.Second, Burst: 3},
+		steps: []step{
+			{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 5 * time.Second}},
+			{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 10 * time.Second}},
+			{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 15 * time.Second}},
+			{at: 0, want: Result{Limit: 3, Remaining: 0, Reset: 15 * time.Second, RetryAfter: 5 * time.Second}},
+			// Another client has its own bucket
+			{at: 0, key: "other", want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 5 * time.Second}},
+			// Half a token has refilled
+			{at: 2500 * time.Millisecond, want: Result{Limit: 3, Remaining: 0, Reset: 12500 * time.Millisecond, RetryAfter: 2500 * time.Millisecond}},
+			{at: 6 * time.Second, want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 14 * time.Second}},
+			// The bucket refills up to its capacity
+			{at: time.Minute, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 5 * time.Second}},
+		},
+	},
+	{
+		name:   "sliding window",
+		policy: config.RateLimitPolicy{Algorithm: config.RateLimitSlidingWindow, Limit: 2, Window: 10 * time.Second},
+		steps: []step{
+			{at: 0, want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 20 * time.Second}},
+			{at: time.Second, want: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 19 * time.Second}},
+			// The current window is full, so the next request fits once the
+			// next window has elapsed enough for the full previous one to weigh 1
+			{at: 2 * time.Second, want: Result{Limit: 2, Remaining: 0, Reset: 18 * time.Second, RetryAfter: 8 * time.Second}},
+			// Another client has its own window
+			{at: 2 * time.Second, key: "other", want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 18 * time.Second}},
+			// The previous window weighs 2*0.8 and lets one more request through
+			{at: 12 * time.Second, want: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 18 * time.Second}},
+			// The previous window must weigh less than 1 for the next request
+			{at: 13 * time.Second, want: Result{Limit: 2, Remaining: 0, Reset: 17 * time.Second, RetryAfter: 2 * time.Second}},
+			// The last window's single request becomes the previous count
+			{at: 25 * time.Second, want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 15 * time.Second}},
+			// Windows long past are forgotten
+			{at: time.Minute, want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 20 * time.Second}},
+		},
+	},
+}
+
+// testPolicies runs the policy tests on stores created by newStore
+func testPolicies(t *testing.T, newStore func(t *testing.T) Store) {
+	for _, tt := range policyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPolicy("test", tt.policy, newStore(t))
+			for i, step := range tt.steps {
+				key := step.key
+				if key == "" {
+					key = "client"
+				}
+
+				got, err := policy.Allow(context.Background(), key, start.Add(step.at))
+				if err != nil {
+					t.Fatalf("step %d: Allow() error = %v", i+1, err)
+				}
+				if !sameResult(got, step.want) {
+					t.Errorf("step %d at +%s: Allow() = %+v, want %+v", i+1, step.at, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+// sameResult compares results, allowing for rounding in the durations
+func sameResult(a, b Result) bool {
+	near := func(x, y time.Duration) bool {
+		return (x - y).Abs() < time.Millisecond
+	}
+	return a.Allowed == b.Allowed && a.Limit == b.Limit && a.Remaining == b.Remaining &&
+		near(a.Reset, b.Reset) && near(a.RetryAfter, b.RetryAfter)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testPolicies(t, func(*testing.T) Store {
+		return NewMemoryStore()
+	})
+}
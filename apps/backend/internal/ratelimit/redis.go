@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeTokenScript refills and takes from the token bucket hash at KEYS[1].
+// ARGV: capacity, rate in tokens per millisecond, now in milliseconds.
+// Returns {allowed, tokens}, tokens as a string since Redis truncates numbers.
+var takeTokenScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1]) or capacity
+local updated = tonumber(state[2]) or now
+if now > updated then
+	tokens = math.min(capacity, tokens + (now - updated) * rate)
+	updated = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", updated)
+redis.call("PEXPIRE", KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// countRequestScript counts a request in the sliding window hash at KEYS[1].
+// ARGV: limit, window size in milliseconds, now in milliseconds.
+// Returns {allowed, previous, current, elapsed}, elapsed as a string.
+var countRequestScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local size = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local window = math.floor(now / size)
+local state = redis.call("HMGET", KEYS[1], "window", "previous", "current")
+local stored = tonumber(state[1])
+local previous, current = 0, 0
+-- Another instance's clock may be slightly ahead
+if stored ~= nil and stored >= window then
+	window = stored
+	previous = tonumber(state[2]) or 0
+	current = tonumber(state[3]) or 0
+elseif stored == window - 1 then
+	previous = tonumber(state[3]) or 0
+end
+
+local elapsed = math.min(math.max((now - window * size) / size, 0), 1)
+local allowed = 0
+if previous * (1 - elapsed) + current < limit then
+	current = current + 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "window", window, "previous", previous, "current", current)
+redis.call("PEXPIRE", KEYS[1], size * 2)
+return {allowed, previous, current, tostring(elapsed)}
+`)
+
+// RedisStore keeps rate limit state in Redis, or anything speaking its
+// protocol and scripting such as Valkey or KeyDB, so that every instance
+// enforces the same limits. Each key is a single hash updated by a Lua
+// script, which keeps updates atomic and works with Redis Cluster.
+type RedisStore struct {
+	client redis.Scripter
+}
+
+// NewRedisStore creates a store on a Redis client, ring or cluster client
+func NewRedisStore(client redis.Scripter) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// TakeToken implements Store
+func (s *RedisStore) TakeToken(ctx context.Context, key string, bucket Bucket, now time.Time) (BucketState, error) {
+	reply, err := takeTokenScript.Run(ctx, s.client, []string{key},
+		bucket.Capacity, bucket.Rate/1000, now.UnixMilli()).Slice()
+	if err != nil {
+		return BucketState{}, err
+	}
+	if len(reply) != 2 {
+		return BucketState{}, fmt.Errorf("unexpected token bucket reply %v", reply)
+	}
+
+	tokens, err := strconv.ParseFloat(fmt.Sprint(reply[1]), 64)
+	if err != nil {
+		return BucketState{}, fmt.Errorf("unexpected token count %v: %w", reply[1], err)
+	}
+	return BucketState{Allowed: reply[0] == int64(1), Tokens: tokens}, nil
+}
+
+// CountRequest implements Store
+func (s *RedisStore) CountRequest(ctx context.Context, key string, window Window, now time.Time) (WindowState, error) {
+	reply, err := countRequestScript.Run(ctx, s.client, []string{key},
+		window.Limit, window.Size.Milliseconds(), now.UnixMilli()).Slice()
+	if err != nil {
+		return WindowState{}, err
+	}
+	if len(reply) != 4 {
+		return WindowState{}, fmt.Errorf("unexpected sliding window reply %v", reply)
+	}
+
+	previous, okPrevious := reply[1].(int64)
+	current, okCurrent := reply[2].(int64)
+	elapsed, err := strconv.ParseFloat(fmt.Sprint(reply[3]), 64)
+	if !okPrevious || !okCurrent || err != nil {
+		return WindowState{}, fmt.Errorf("unexpected sliding window reply %v", reply)
+	}
+	return WindowState{Allowed: reply[0] == int64(1), Previous: previous, Current: current, Elapsed: elapsed}, nil
+}
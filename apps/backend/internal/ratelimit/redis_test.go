@@ -0,0 +1,16 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStore(t *testing.T) {
+	testPolicies(t, func(t *testing.T) Store {
+		client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		return NewRedisStore(client)
+	})
+}
@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket that holds up to Capacity tokens and refills at
+// Rate tokens per second. Each request takes a token.
+type Bucket struct {
+	Capacity float64
+	Rate     float64
+}
+
+// BucketState is the outcome of taking a token
+type BucketState struct {
+	Allowed bool
+	// Tokens is how many tokens are left after the request
+	Tokens float64
+}
+
+// Window is a sliding window allowing Limit requests per Size. It counts
+// requests in fixed windows and weighs the previous window's count by how
+// much of it still overlaps the sliding window.
+type Window struct {
+	Limit int64
+	Size  time.Duration
+}
+
+// WindowState is the outcome of counting a request
+type WindowState struct {
+	Allowed bool
+	// Previous and Current are the request counts of the previous and current
+	// fixed windows, including this request when it was allowed
+	Previous int64
+	Current  int64
+	// Elapsed is the fraction of the current fixed window that has passed
+	Elapsed float64
+}
+
+// Store holds rate limit state. Both operations must be atomic, since
+// concurrent requests with the same key may run on different instances.
+type Store interface {
+	// TakeToken refills the bucket at key and takes a token if one is left
+	TakeToken(ctx context.Context, key string, bucket Bucket, now time.Time) (BucketState, error)
+	// CountRequest counts a request in the window at key unless the limit is reached
+	CountRequest(ctx context.Context, key string, window Window, now time.Time) (WindowState, error)
+}
+
+// sweepInterval is how often the memory store drops expired state
+const sweepInterval = time.Minute
+
+// MemoryStore keeps rate limit state in the process. It suits a single
+// instance; with several, each one enforces the limits on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucketEntry
+	windows   map[string]*windowEntry
+	lastSweep time.Time
+}
+
+type bucketEntry struct {
+	tokens  float64
+	updated time.Time
+	expires time.Time
+}
+
+type windowEntry struct {
+	window   int64
+	previous int64
+	current  int64
+	expires  time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucketEntry),
+		windows: make(map[string]*windowEntry),
+	}
+}
+
+// TakeToken implements Store
+func (s *MemoryStore) TakeToken(_ context.Context, key string, bucket Bucket, now time.Time) (BucketState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	entry, ok := s.buckets[key]
+	if !ok {
+		entry = &bucketEntry{tokens: bucket.Capacity, updated: now}
+		s.buckets[key] = entry
+	}
+	if elapsed := now.Sub(entry.updated); elapsed > 0 {
+		entry.tokens = math.Min(bucket.Capacity, entry.tokens+elapsed.Seconds()*bucket.Rate)
+		entry.updated = now
+	}
+
+	state := BucketState{}
+	if entry.tokens >= 1 {
+		entry.tokens--
+		state.Allowed = true
+	}
+	state.Tokens = entry.tokens
+	// A bucket that would be full again holds nothing worth keeping
+	entry.expires = now.Add(time.Duration((bucket.Capacity - entry.tokens) / bucket.Rate * float64(time.Second)))
+	return state, nil
+}
+
+// CountRequest implements Store
+func (s *MemoryStore) CountRequest(_ context.Context, key string, window Window, now time.Time) (WindowState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	current := now.UnixMilli() / window.Size.Milliseconds()
+	entry, ok := s.windows[key]
+	switch {
+	case !ok:
+		entry = &windowEntry{window: current}
+		s.windows[key] = entry
+	case entry.window == current-1:
+		entry.window, entry.previous, entry.current = current, entry.current, 0
+	case entry.window < current-1:
+		entry.window, entry.previous, entry.current = current, 0, 0
+	}
+
+	state := WindowState{
+		Elapsed: float64(now.UnixMilli()-current*window.Size.Milliseconds()) / float64(window.Size.Milliseconds()),
+	}
+	if float64(entry.previous)*(1-state.Elapsed)+float64(entry.current) < float64(window.Limit) {
+		entry.current++
+		state.Allowed = true
+	}
+	state.Previous, state.Current = entry.previous, entry.current
+	entry.expires = now.Add(2 * window.Size)
+	return state, nil
+}
+
+// sweep drops expired state, at most once per sweepInterval
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.buckets {
+		if now.After(entry.expires) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, entry := range s.windows {
+		if now.After(entry.expires) {
+			delete(s.windows, key)
+		}
+	}
+}
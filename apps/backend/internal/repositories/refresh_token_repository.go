@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenNotFound is returned when no refresh token matches a lookup
+var ErrRefreshTokenNotFound = apperrors.NotFound("refresh_token_not_found", "Refresh token not found")
+
+// RefreshTokenRepository defines the interface for refresh token data operations
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Revoke(ctx context.Context, id uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID uint) error
+}
+
+// refreshTokenRepository implements RefreshTokenRepository
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create stores a new refresh token
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := conn(ctx, r.db).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke marks a single refresh token as revoked. It reports false when the
+// token had already been revoked, which lets callers detect concurrent reuse.
+func (r *refreshTokenRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every active refresh token in a token family
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return conn(ctx, r.db).
+		Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now().UTC()).Error
+}
+
+// RevokeAllForUser revokes every active refresh token issued to a user
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now().UTC()).Error
+}
@@ -6,14 +6,23 @@ import (
 
 // Repositories holds all repository instances
 type Repositories struct {
-	User UserRepository
+	User         UserRepository
+	Role         RoleRepository
+	RefreshToken RefreshTokenRepository
 	// Add more repositories here as you create them
+
+	// Tx runs units of work that span several repositories
+	Tx TxManager
 }
 
 // NewRepositories creates a new Repositories instance with all repositories
 func NewRepositories(db *gorm.DB) *Repositories {
 	return &Repositories{
-		User: NewUserRepository(db),
+		User:         NewUserRepository(db),
+		Role:         NewRoleRepository(db),
+		RefreshToken: NewRefreshTokenRepository(db),
 		// Add more repositories here as you create them
+
+		Tx: NewTxManager(db),
 	}
 }
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Model is satisfied by pointers to models that embed models.BaseModel
+type Model[T any] interface {
+	*T
+	Base() *models.BaseModel
+	Position() (time.Time, uint)
+}
+
+// ResourceErrors are the domain errors a generic repository returns
+type ResourceErrors struct {
+	NotFound *apperrors.Error
+	Conflict *apperrors.Error
+	Modified *apperrors.Error
+}
+
+// NewResourceErrors derives the domain errors for a resource from its name,
+// e.g. "product" gives product_not_found, product_conflict and product_modified
+func NewResourceErrors(name string) ResourceErrors {
+	code := strings.ReplaceAll(strings.ToLower(name), " ", "_")
+	title := strings.ToUpper(name[:1]) + name[1:]
+
+	return ResourceErrors{
+		NotFound: apperrors.NotFound(code+"_not_found", title+" not found"),
+		Conflict: apperrors.Conflict(code+"_conflict", title+" already exists"),
+		Modified: apperrors.PreconditionFailed(code+"_modified", title+" was modified by another request"),
+	}
+}
+
+// Repository defines generic data operations for a model. Soft-deleted rows
+// are never returned, and writes are guarded by the model's version.
+type Repository[T any] interface {
+	Create(ctx context.Context, entity *T) error
+	Get(ctx context.Context, id uint) (*T, error)
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, entity *T) error
+	List(ctx context.Context, spec *query.Spec, offset, limit int) ([]T, int64, error)
+	ListPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (*pagination.Page[T], error)
+}
+
+// repository implements Repository on top of GORM
+type repository[T any, PT Model[T]] struct {
+	db       *gorm.DB
+	errs     ResourceErrors
+	preloads []string
+}
+
+// NewRepository creates a generic repository for T. Preloads name the
+// associations loaded with every read.
+func NewRepository[T any, PT Model[T]](db *gorm.DB, errs ResourceErrors, preloads ...string) Repository[T] {
+	return &repository[T, PT]{db: db, errs: errs, preloads: preloads}
+}
+
+// Create inserts a new entity. Associations are never written through here.
+func (r *repository[T, PT]) Create(ctx context.Context, entity *T) error {
+	err := conn(ctx, r.db).Omit(clause.Associations).Create(entity).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return r.errs.Conflict
+	}
+	return err
+}
+
+// Get retrieves an entity by ID
+func (r *repository[T, PT]) Get(ctx context.Context, id uint) (*T, error) {
+	var entity T
+	err := r.read(ctx).First(&entity, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, r.errs.NotFound
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Update writes every field of entity, provided the stored version still
+// matches, and increments the version
+func (r *repository[T, PT]) Update(ctx context.Context, entity *T) error {
+	base := PT(entity).Base()
+	expectedVersion := base.Version
+	base.Version++
+
+	result := conn(ctx, r.db).
+		Model(entity).
+		Where("version = ?", expectedVersion).
+		Select("*").
+		Omit("ID", "CreatedAt", "DeletedAt", clause.Associations).
+		Updates(entity)
+	if result.Error != nil {
+		base.Version = expectedVersion
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return r.errs.Conflict
+		}
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		base.Version = expectedVersion
+		return r.notUpdatedError(ctx, base.ID)
+	}
+	return nil
+}
+
+// Delete soft-deletes an entity, provided the stored version still matches
+func (r *repository[T, PT]) Delete(ctx context.Context, entity *T) error {
+	base := PT(entity).Base()
+
+	result := conn(ctx, r.db).Where("version = ?", base.Version).Delete(new(T), base.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.notUpdatedError(ctx, base.ID)
+	}
+	return nil
+}
+
+// List retrieves a filtered, sorted and paginated list
+func (r *repository[T, PT]) List(ctx context.Context, spec *query.Spec, offset, limit int) ([]T, int64, error) {
+	var entities []T
+	var total int64
+
+	if err := conn(ctx, r.db).Model(new(T)).Scopes(spec.Filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.read(ctx).Scopes(spec.Filter, spec.Order).Offset(offset).Limit(limit).Find(&entities).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+// ListPage retrieves a filtered page using keyset pagination on (created_at, id)
+func (r *repository[T, PT]) ListPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (*pagination.Page[T], error) {
+	return pagination.Keyset[T, PT](r.read(ctx), params, spec.Filter)
+}
+
+// read starts a query that loads the configured associations
+func (r *repository[T, PT]) read(ctx context.Context) *gorm.DB {
+	db := conn(ctx, r.db)
+	for _, preload := range r.preloads {
+		db = db.Preload(preload)
+	}
+	return db
+}
+
+// notUpdatedError explains why a conditional write matched no rows: the
+// entity is either gone or was modified concurrently
+func (r *repository[T, PT]) notUpdatedError(ctx context.Context, id uint) error {
+	var count int64
+	if err := conn(ctx, r.db).Model(new(T)).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return r.errs.NotFound
+	}
+	return r.errs.Modified
+}
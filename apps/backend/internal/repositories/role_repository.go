@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrRoleNotFound is returned when no role matches a lookup
+var ErrRoleNotFound = apperrors.NotFound("role_not_found", "Role not found")
+
+// RoleRepository defines the interface for role data operations
+type RoleRepository interface {
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+}
+
+// roleRepository implements RoleRepository
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// GetByName retrieves a role and its permissions by name
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := conn(ctx, r.db).Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
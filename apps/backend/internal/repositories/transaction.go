@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// TxManager runs units of work that span several repositories
+type TxManager interface {
+	// WithinTransaction runs fn in a database transaction. Repository calls
+	// made with the context passed to fn take part in the transaction, which
+	// is committed when fn returns nil and rolled back when it returns an
+	// error or panics; the panic is then re-raised. Calling WithinTransaction
+	// again inside fn runs the inner fn in a savepoint, so an inner error
+	// rolls back only the inner work.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txManager implements TxManager on top of GORM
+type txManager struct {
+	db *gorm.DB
+}
+
+// txKey is the context key holding the current transaction
+type txKey struct{}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+// WithinTransaction runs fn in a transaction, or in a savepoint when ctx already carries one
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or db when there is none,
+// bound to ctx. Repositories must use it for every query so that they
+// participate in the caller's unit of work.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
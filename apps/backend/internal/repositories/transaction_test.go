@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB opens GORM's postgres dialect on a mock connection that expects
+// the statements the test sets up, in order
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		_ = sqlDB.Close()
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// insert records an event through the connection ctx selects, as repositories do
+func insert(ctx context.Context, db *gorm.DB, name string) error {
+	return conn(ctx, db).Exec("INSERT INTO events (name) VALUES (?)", name).Error
+}
+
+func TestWithinTransactionCommits(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO events").WithArgs("outer").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := NewTxManager(db).WithinTransaction(context.Background(), func(ctx context.Context) error {
+		return insert(ctx, db, "outer")
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction() error = %v", err)
+	}
+}
+
+func TestWithinTransactionRollsBackOnError(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO events").WithArgs("outer").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	failure := errors.New("failure")
+	err := NewTxManager(db).WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := insert(ctx, db, "outer"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithinTransaction() error = %v, want %v", err, failure)
+	}
+}
+
+func TestNestedTransactionRollsBackToSavepoint(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO events").WithArgs("outer").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("^SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO events").WithArgs("inner").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("^ROLLBACK TO SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	// The outer work goes on after the inner failure and is committed
+	mock.ExpectExec("INSERT INTO events").WithArgs("after").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	tx := NewTxManager(db)
+	failure := errors.New("inner failure")
+	err := tx.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := insert(ctx, db, "outer"); err != nil {
+			return err
+		}
+		innerErr := tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, db, "inner"); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(innerErr, failure) {
+			t.Errorf("inner WithinTransaction() error = %v, want %v", innerErr, failure)
+		}
+		return insert(ctx, db, "after")
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction() error = %v", err)
+	}
+}
+
+func TestWithinTransactionRollsBackAndRepanics(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO events").WithArgs("outer").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want the original panic", r)
+		}
+	}()
+	_ = NewTxManager(db).WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := insert(ctx, db, "outer"); err != nil {
+			return err
+		}
+		panic("boom")
+	})
+	t.Error("WithinTransaction() returned instead of re-panicking")
+}
+
+func TestConnUsesTransactionFromContext(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	type ctxKey struct{}
+	base := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	if _, ok := conn(base, db).Statement.ConnPool.(*sql.Tx); ok {
+		t.Error("conn() outside a transaction returned a transaction")
+	}
+
+	err := NewTxManager(db).WithinTransaction(base, func(ctx context.Context) error {
+		got := conn(ctx, db)
+		if _, ok := got.Statement.ConnPool.(*sql.Tx); !ok {
+			t.Errorf("conn() inside a transaction uses %T, want *sql.Tx", got.Statement.ConnPool)
+		}
+		if got.Statement.Context.Value(ctxKey{}) != "request" {
+			t.Error("conn() is not bound to the caller's context")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction() error = %v", err)
+	}
+}
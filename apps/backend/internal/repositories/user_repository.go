@@ -3,19 +3,62 @@ package repositories
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
 	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// User errors returned by UserRepository
+var (
+	ErrUserNotFound   = apperrors.NotFound("user_not_found", "User not found")
+	ErrUsernameTaken  = apperrors.Conflict("username_taken", "Username already exists")
+	ErrUserModified   = apperrors.PreconditionFailed("user_modified", "User was modified by another request")
+	ErrUserNotDeleted = apperrors.Conflict("user_not_deleted", "User is not deleted")
+)
+
+// UserQuery is the list query schema for users. Search uses the trigram index
+// created by the add_users_search_index migration.
+var UserQuery = &query.Schema{
+	Filters: map[string]query.Filter{
+		"is_active": {Column: "is_active", Type: query.Bool},
+		"username":  {Column: "username", Type: query.String},
+	},
+	Sorts: map[string]string{
+		"id":         "id",
+		"username":   "username",
+		"first_name": "first_name",
+		"last_name":  "last_name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	Search:      userSearchExpression,
+	DefaultSort: []query.Sort{{Field: "created_at", Desc: true}},
+	TieBreaker:  "id",
+	Trashed:     true,
+}
+
+// userSearchExpression must match the expression of idx_users_search exactly for the index to be used
+const userSearchExpression = "(username || ' ' || COALESCE(first_name, '') || ' ' || COALESCE(last_name, ''))"
+
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByIDWithTrashed(ctx context.Context, id uint) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id uint) error
-	List(ctx context.Context, offset, limit int) ([]models.User, int64, error)
+	Delete(ctx context.Context, user *models.User) error
+	Restore(ctx context.Context, user *models.User) error
+	HardDelete(ctx context.Context, user *models.User) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	List(ctx context.Context, spec *query.Spec, offset, limit int) ([]models.User, int64, error)
+	ListPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (*pagination.Page[models.User], error)
+	AddRole(ctx context.Context, user *models.User, role *models.Role) error
 }
 
 // userRepository implements UserRepository
@@ -28,18 +71,36 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-// Create creates a new user
+// Create creates a new user. Associations such as roles are never written
+// through here, so request bodies cannot grant themselves roles.
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	err := conn(ctx, r.db).Omit(clause.Associations).Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrUsernameTaken
+	}
+	return err
 }
 
 // GetByID retrieves a user by ID
 func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
+	err := conn(ctx, r.db).Preload("Roles.Permissions").First(&user, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByIDWithTrashed retrieves a user by ID, including soft-deleted users
+func (r *userRepository) GetByIDWithTrashed(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := conn(ctx, r.db).Unscoped().Preload("Roles.Permissions").First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -49,39 +110,184 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, er
 // GetByUsername retrieves a user by username
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	err := conn(ctx, r.db).Preload("Roles.Permissions").Where("username = ?", username).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-// Update updates an existing user
+// Update updates an existing user, leaving its associations untouched. The
+// update only applies if the stored version still matches user.Version, in
+// which case the version is incremented; otherwise ErrUserModified is returned.
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	expectedVersion := user.Version
+	user.Version++
+
+	result := conn(ctx, r.db).
+		Model(user).
+		Where("version = ?", expectedVersion).
+		Select("Username", "Password", "FirstName", "LastName", "IsActive", "UpdatedAt", "Version").
+		Updates(user)
+	if result.Error != nil {
+		user.Version = expectedVersion
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return ErrUsernameTaken
+		}
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		user.Version = expectedVersion
+		return r.notUpdatedError(ctx, user.ID)
+	}
+	return nil
 }
 
-// Delete deletes a user by ID
-func (r *userRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
+// Delete deletes a user, provided the stored version still matches user.Version
+func (r *userRepository) Delete(ctx context.Context, user *models.User) error {
+	result := conn(ctx, r.db).Where("version = ?", user.Version).Delete(&models.User{}, user.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.notUpdatedError(ctx, user.ID)
+	}
+	return nil
 }
 
-// List retrieves a paginated list of users
-func (r *userRepository) List(ctx context.Context, offset, limit int) ([]models.User, int64, error) {
+// Restore undeletes a soft-deleted user, provided the stored version still
+// matches user.Version, and increments the version. Restoring fails with
+// ErrUsernameTaken if the username was registered again in the meantime.
+func (r *userRepository) Restore(ctx context.Context, user *models.User) error {
+	now := time.Now()
+
+	result := conn(ctx, r.db).
+		Unscoped().
+		Model(&models.User{}).
+		Where("id = ? AND version = ? AND deleted_at IS NOT NULL", user.ID, user.Version).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"updated_at": now,
+			"version":    user.Version + 1,
+		})
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return ErrUsernameTaken
+		}
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		stored, err := r.GetByIDWithTrashed(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if !stored.DeletedAt.Valid {
+			return ErrUserNotDeleted
+		}
+		return ErrUserModified
+	}
+
+	user.DeletedAt = gorm.DeletedAt{}
+	user.UpdatedAt = now
+	user.Version++
+	return nil
+}
+
+// HardDelete permanently deletes a user, soft-deleted or not, provided the
+// stored version still matches user.Version. Role assignments and refresh
+// tokens are removed by the database's cascading foreign keys.
+func (r *userRepository) HardDelete(ctx context.Context, user *models.User) error {
+	result := conn(ctx, r.db).Unscoped().Where("version = ?", user.Version).Delete(&models.User{}, user.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByIDWithTrashed(ctx, user.ID); err != nil {
+			return err
+		}
+		return ErrUserModified
+	}
+	return nil
+}
+
+// PurgeDeleted permanently deletes users that were soft-deleted before the given time
+func (r *userRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Unscoped().Where("deleted_at < ?", before).Delete(&models.User{})
+	return result.RowsAffected, result.Error
+}
+
+// notUpdatedError explains why a conditional write matched no rows: the user
+// is either gone or was modified concurrently
+func (r *userRepository) notUpdatedError(ctx context.Context, id uint) error {
+	var count int64
+	if err := conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+	return ErrUserModified
+}
+
+// List retrieves a filtered, sorted and paginated list of users
+func (r *userRepository) List(ctx context.Context, spec *query.Spec, offset, limit int) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&models.User{}).Scopes(spec.Filter).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	if err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	err := conn(ctx, r.db).
+		Preload("Roles").
+		Scopes(spec.Filter, spec.Order).
+		Offset(offset).
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
 		return nil, 0, err
 	}
 	return users, total, nil
 }
+
+// ListPage retrieves a filtered page of users using keyset pagination on (created_at, id)
+func (r *userRepository) ListPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (*pagination.Page[models.User], error) {
+	return pagination.Keyset[models.User](conn(ctx, r.db).Preload("Roles"), params, spec.Filter)
+}
+
+// AddRole assigns a role to a user, doing nothing if it is already assigned.
+// Roles are part of the user's representation, so assigning one increments
+// the version and the user's entity tag changes with it.
+func (r *userRepository) AddRole(ctx context.Context, user *models.User, role *models.Role) error {
+	now := time.Now()
+	assigned := false
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+			user.ID, role.ID)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		assigned = true
+
+		return tx.Model(&models.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"updated_at": now,
+				"version":    gorm.Expr("version + 1"),
+			}).Error
+	})
+	if err != nil || !assigned {
+		return err
+	}
+
+	user.Roles = append(user.Roles, *role)
+	user.UpdatedAt = now
+	user.Version++
+	return nil
+}
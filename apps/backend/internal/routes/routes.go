@@ -2,29 +2,50 @@ package routes
 
 import (
 	"github.com/albuquerquewizard/monorepo/backend/internal/controllers"
-	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/albuquerquewizard/monorepo/backend/internal/middleware"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/ratelimit"
+	"github.com/albuquerquewizard/monorepo/backend/internal/services"
 	"github.com/gofiber/fiber/v2"
 )
 
 // SetupRoutes configures all the routes for the application
-func SetupRoutes(app *fiber.App, controllers *controllers.Controllers) {
+func SetupRoutes(app *fiber.App, controllers *controllers.Controllers, services *services.Services, limiter *ratelimit.Limiter) {
 	api := app.Group("/api")
 
-	// Health check endpoint
-	api.Get("/health", func(c *fiber.Ctx) error {
-		return utils.SuccessResponse(c, "Welcome to Fiber API", fiber.Map{
-			"status":  "success",
-			"version": "1.0.0",
-		})
-	})
+	// Every API request counts against the client's overall limit
+	api.Use(limiter.Middleware("api"))
 
-	// User routes (public for practice projects)
+	// Authenticated routes are also limited per user
+	requireAuth := middleware.RequireAuth(services.Auth, services.User)
+	perUser := limiter.Middleware("user")
+
+	// Auth routes
+	auth := api.Group("/auth")
+	auth.Post("/login", limiter.Middleware("login"), controllers.Auth.Login)
+	auth.Post("/refresh", limiter.Middleware("refresh"), controllers.Auth.Refresh)
+	auth.Post("/logout", controllers.Auth.Logout)
+
+	// Current user
+	api.Get("/me", requireAuth, perUser, controllers.User.GetMe)
+
+	// User routes (sign-up is public, users manage their own record, admins manage everyone)
 	users := api.Group("/users")
-	users.Post("/", controllers.User.CreateUser)
-	users.Get("/", controllers.User.ListUsers)
-	users.Get("/:id", controllers.User.GetUser)
-	users.Put("/:id", controllers.User.UpdateUser)
-	users.Delete("/:id", controllers.User.DeleteUser)
+	users.Post("/", limiter.Middleware("signup"), controllers.User.CreateUser)
+	users.Get("/", requireAuth, perUser,
+		middleware.RequirePermission(models.PermissionUsersList), controllers.User.ListUsers)
+	users.Get("/:id", requireAuth, perUser,
+		middleware.RequireSelfOrPermission("id", models.PermissionUsersRead), controllers.User.GetUser)
+	users.Put("/:id", requireAuth, perUser,
+		middleware.RequireSelfOrPermission("id", models.PermissionUsersUpdate), controllers.User.UpdateUser)
+	users.Patch("/:id", requireAuth, perUser,
+		middleware.RequireSelfOrPermission("id", models.PermissionUsersUpdate), controllers.User.PatchUser)
+	users.Delete("/:id", requireAuth, perUser,
+		middleware.RequireSelfOrPermission("id", models.PermissionUsersDelete), controllers.User.DeleteUser)
+	users.Post("/:id/restore", requireAuth, perUser,
+		middleware.RequirePermission(models.PermissionUsersRestore), controllers.User.RestoreUser)
+	users.Delete("/:id/permanent", requireAuth, perUser,
+		middleware.RequirePermission(models.PermissionUsersPurge), controllers.User.HardDeleteUser)
 
 	// TODO: Add more API routes here
 }
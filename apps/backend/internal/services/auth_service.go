@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+	"github.com/albuquerquewizard/monorepo/backend/internal/tracing"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Token errors returned by AuthService
+var (
+	ErrInvalidAccessToken  = apperrors.Unauthorized("invalid_access_token", "Invalid or expired access token")
+	ErrInvalidRefreshToken = apperrors.Unauthorized("invalid_refresh_token", "Invalid or expired refresh token")
+	ErrRefreshTokenReused  = apperrors.Unauthorized("refresh_token_reused", "Refresh token has already been used")
+)
+
+// refreshTokenBytes is the amount of randomness in an opaque refresh token
+const refreshTokenBytes = 32
+
+// TokenPair is the set of credentials issued on login and refresh
+type TokenPair struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// AuthService defines the interface for authentication business logic
+type AuthService interface {
+	Login(ctx context.Context, username, password string) (*models.User, *TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	VerifyAccessToken(accessToken string) (uint, error)
+}
+
+// authService implements AuthService
+type authService struct {
+	userService      UserService
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	jwt              config.JWTConfig
+}
+
+// NewAuthService creates a new auth service
+func NewAuthService(
+	userService UserService,
+	userRepo repositories.UserRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	jwtConfig config.JWTConfig,
+) AuthService {
+	return &authService{
+		userService:      userService,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		jwt:              jwtConfig,
+	}
+}
+
+// Login verifies the user's credentials and starts a new refresh token family
+func (s *authService) Login(ctx context.Context, username, password string) (user *models.User, tokens *TokenPair, err error) {
+	ctx, end := tracing.StartSpan(ctx, "AuthService.Login")
+	defer end(&err)
+
+	user, err = s.userService.AuthenticateUser(ctx, username, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err = s.issueTokens(ctx, user, uuid.NewString())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// Refresh rotates a refresh token and issues a new token pair. Presenting a
+// token that has already been rotated revokes every token in its family.
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (tokens *TokenPair, err error) {
+	ctx, end := tracing.StartSpan(ctx, "AuthService.Refresh")
+	defer end(&err)
+
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if stored.IsRevoked() {
+		return nil, s.revokeReusedFamily(ctx, stored.FamilyID)
+	}
+
+	if stored.IsExpired(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Revoking is conditional, so only one of two concurrent refreshes wins
+	revoked, err := s.refreshTokenRepo.Revoke(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, s.revokeReusedFamily(ctx, stored.FamilyID)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, err
+	}
+	if err != nil || !user.IsActive {
+		if revokeErr := s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); revokeErr != nil {
+			return nil, revokeErr
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issueTokens(ctx, user, stored.FamilyID)
+}
+
+// Logout revokes the token family the given refresh token belongs to
+func (s *authService) Logout(ctx context.Context, refreshToken string) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "AuthService.Logout")
+	defer end(&err)
+
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// VerifyAccessToken validates a signed access token and returns the ID of the user it was issued to
+func (s *authService) VerifyAccessToken(accessToken string) (uint, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(s.jwt.Secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.jwt.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return 0, ErrInvalidAccessToken.Wrap(err)
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidAccessToken.Wrap(err)
+	}
+
+	return uint(userID), nil
+}
+
+// revokeReusedFamily revokes a token family after a rotated token was replayed
+func (s *authService) revokeReusedFamily(ctx context.Context, familyID string) error {
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// issueTokens signs a new access token and persists a new refresh token in the given family
+func (s *authService) issueTokens(ctx context.Context, user *models.User, familyID string) (*TokenPair, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.jwt.AccessTokenTTL)
+
+	claims := jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Issuer:    s.jwt.Issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwt.Secret))
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.Create(ctx, &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashRefreshToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(s.jwt.RefreshTokenTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.jwt.AccessTokenTTL.Seconds()),
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// generateRefreshToken returns a new opaque, URL-safe refresh token
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashRefreshToken returns the hex-encoded SHA-256 hash stored for a refresh token
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/testutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "Secret123"
+
+// newTestAuthService creates an auth service for the given users, whose
+// password is testPassword, backed by in-memory repositories
+func newTestAuthService(t *testing.T, users ...*models.User) (*authService, *fakeRefreshTokenRepo) {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range users {
+		user.Password = string(hash)
+	}
+
+	userRepo := testutil.NewUserRepository(users...)
+	tokens := &fakeRefreshTokenRepo{}
+	return &authService{
+		userService:      &userService{userRepo: userRepo, refreshTokenRepo: tokens, tx: fakeTxManager{}},
+		userRepo:         userRepo,
+		refreshTokenRepo: tokens,
+		jwt: config.JWTConfig{
+			Secret:          "test-secret",
+			Issuer:          "test",
+			AccessTokenTTL:  time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+	}, tokens
+}
+
+func activeUser(id uint, username string) *models.User {
+	return &models.User{BaseModel: models.BaseModel{ID: id}, Username: username, IsActive: true}
+}
+
+func TestLoginIssuesTokens(t *testing.T) {
+	ctx := context.Background()
+	auth, tokens := newTestAuthService(t, activeUser(1, "alice"))
+
+	user, pair, err := auth.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if user.ID != 1 {
+		t.Errorf("Login() user ID = %d, want 1", user.ID)
+	}
+
+	userID, err := auth.VerifyAccessToken(pair.AccessToken)
+	if err != nil || userID != 1 {
+		t.Errorf("VerifyAccessToken() = %d, %v, want 1, nil", userID, err)
+	}
+
+	stored, err := tokens.GetByHash(ctx, hashRefreshToken(pair.RefreshToken))
+	if err != nil {
+		t.Fatalf("refresh token was not stored: %v", err)
+	}
+	if stored.TokenHash == pair.RefreshToken {
+		t.Error("refresh token was stored in plain text")
+	}
+}
+
+func TestLoginRejectsInvalidCredentials(t *testing.T) {
+	inactive := activeUser(2, "bob")
+	inactive.IsActive = false
+	auth, tokens := newTestAuthService(t, activeUser(1, "alice"), inactive)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     error
+	}{
+		{"wrong password", "alice", "Wrong1234", ErrInvalidCredentials},
+		{"unknown user", "carol", testPassword, ErrInvalidCredentials},
+		{"inactive user", "bob", testPassword, ErrUserInactive},
+		{"inactive user, wrong password", "bob", "Wrong1234", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := auth.Login(context.Background(), tt.username, tt.password)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Login() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if n := tokens.active(); n != 0 {
+		t.Errorf("%d refresh tokens issued for failed logins", n)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	ctx := context.Background()
+	auth, tokens := newTestAuthService(t, activeUser(1, "alice"))
+
+	_, first, err := auth.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := auth.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh() returned the same refresh token")
+	}
+
+	old, _ := tokens.GetByHash(ctx, hashRefreshToken(first.RefreshToken))
+	rotated, _ := tokens.GetByHash(ctx, hashRefreshToken(second.RefreshToken))
+	if !old.IsRevoked() {
+		t.Error("rotated refresh token is still active")
+	}
+	if rotated.IsRevoked() || rotated.FamilyID != old.FamilyID {
+		t.Errorf("new refresh token revoked = %v, family = %q, want active in family %q",
+			rotated.IsRevoked(), rotated.FamilyID, old.FamilyID)
+	}
+
+	if _, err := auth.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Errorf("Refresh() with the new token error = %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	auth, tokens := newTestAuthService(t, activeUser(1, "alice"))
+
+	_, first, err := auth.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second login starts another family, which reuse must not touch
+	_, other, err := auth.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := auth.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := auth.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("Refresh() with a rotated token error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if _, err := auth.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("Refresh() with the family's latest token error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if _, err := auth.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("Refresh() in another family error = %v", err)
+	}
+	if n := tokens.active(); n != 1 {
+		t.Errorf("%d active refresh tokens, want only the other family's", n)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare returns the refresh token to present
+		prepare func(t *testing.T, auth *authService, tokens *fakeRefreshTokenRepo, users *testutil.UserRepository) string
+	}{
+		{
+			name: "unknown token",
+			prepare: func(*testing.T, *authService, *fakeRefreshTokenRepo, *testutil.UserRepository) string {
+				return "not-a-token"
+			},
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, auth *authService, tokens *fakeRefreshTokenRepo, _ *testutil.UserRepository) string {
+				pair := login(t, auth)
+				tokens.tokens[0].ExpiresAt = time.Now().Add(-time.Second)
+				return pair.RefreshToken
+			},
+		},
+		{
+			name: "deactivated user",
+			prepare: func(t *testing.T, auth *authService, _ *fakeRefreshTokenRepo, users *testutil.UserRepository) string {
+				pair := login(t, auth)
+				users.Users[1].IsActive = false
+				return pair.RefreshToken
+			},
+		},
+		{
+			name: "deleted user",
+			prepare: func(t *testing.T, auth *authService, _ *fakeRefreshTokenRepo, users *testutil.UserRepository) string {
+				pair := login(t, auth)
+				delete(users.Users, 1)
+				return pair.RefreshToken
+			},
+		},
+		{
+			name: "logged out",
+			prepare: func(t *testing.T, auth *authService, _ *fakeRefreshTokenRepo, _ *testutil.UserRepository) string {
+				pair := login(t, auth)
+				if err := auth.Logout(context.Background(), pair.RefreshToken); err != nil {
+					t.Fatal(err)
+				}
+				return pair.RefreshToken
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, tokens := newTestAuthService(t, activeUser(1, "alice"))
+			refreshToken := tt.prepare(t, auth, tokens, auth.userRepo.(*testutil.UserRepository))
+			issued := len(tokens.tokens)
+
+			pair, err := auth.Refresh(context.Background(), refreshToken)
+			if err == nil || pair != nil {
+				t.Fatalf("Refresh() = %v, %v, want an error", pair, err)
+			}
+			if !errors.Is(err, ErrInvalidRefreshToken) && !errors.Is(err, ErrRefreshTokenReused) {
+				t.Errorf("Refresh() error = %v, want an unauthorized refresh token error", err)
+			}
+			if len(tokens.tokens) != issued {
+				t.Error("Refresh() issued a refresh token despite failing")
+			}
+		})
+	}
+}
+
+func TestConcurrentRefreshOnlyOneWins(t *testing.T) {
+	ctx := context.Background()
+	auth, tokens := newTestAuthService(t, activeUser(1, "alice"))
+	pair := login(t, auth)
+
+	// Both refreshes read the token before either revokes it
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	tokens.beforeRevoke = func() {
+		arrived.Done()
+		arrived.Wait()
+	}
+
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := auth.Refresh(ctx, pair.RefreshToken)
+			errs <- err
+		}()
+	}
+
+	var succeeded, reused int
+	for range 2 {
+		switch err := <-errs; {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrRefreshTokenReused):
+			reused++
+		default:
+			t.Errorf("Refresh() unexpected error = %v", err)
+		}
+	}
+	if succeeded != 1 || reused != 1 {
+		t.Errorf("got %d successful and %d reused refreshes, want 1 and 1", succeeded, reused)
+	}
+	// The losing refresh treats the race as reuse and revokes the winner's token too
+	if n := tokens.active(); n != 0 {
+		t.Errorf("%d refresh tokens still active after a concurrent reuse", n)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	auth, tokens := newTestAuthService(t, activeUser(1, "alice"))
+
+	first := login(t, auth)
+	second, err := auth.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := auth.Logout(ctx, first.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if n := tokens.active(); n != 0 {
+		t.Errorf("%d refresh tokens still active after logout", n)
+	}
+	if _, err := auth.Refresh(ctx, second.RefreshToken); err == nil {
+		t.Error("Refresh() succeeded after logout")
+	}
+	if err := auth.Logout(ctx, "not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Logout() with an unknown token error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+// login logs in as alice and returns the token pair
+func login(t *testing.T, auth *authService) *TokenPair {
+	t.Helper()
+
+	_, pair, err := auth.Login(context.Background(), "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair
+}
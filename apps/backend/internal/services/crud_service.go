@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+	"github.com/albuquerquewizard/monorepo/backend/internal/tracing"
+	"github.com/go-playground/validator/v10"
+)
+
+// CRUDService defines generic business logic for a resource: entities are
+// validated with their validate tags before they are written
+type CRUDService[T any] interface {
+	Create(ctx context.Context, entity *T) error
+	Get(ctx context.Context, id uint) (*T, error)
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, entity *T) error
+	List(ctx context.Context, spec *query.Spec, offset, limit int) ([]T, int64, error)
+	ListPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (*pagination.Page[T], error)
+}
+
+// crudService implements CRUDService
+type crudService[T any] struct {
+	repo     repositories.Repository[T]
+	validate *validator.Validate
+	// spanPrefix names the spans after the entity type, e.g. "ProductService."
+	spanPrefix string
+}
+
+// NewCRUDService creates a generic service on top of a repository
+func NewCRUDService[T any](repo repositories.Repository[T], validate *validator.Validate) CRUDService[T] {
+	return &crudService[T]{
+		repo:       repo,
+		validate:   validate,
+		spanPrefix: reflect.TypeFor[T]().Name() + "Service.",
+	}
+}
+
+// Create validates and stores a new entity
+func (s *crudService[T]) Create(ctx context.Context, entity *T) (err error) {
+	ctx, end := tracing.StartSpan(ctx, s.spanPrefix+"Create")
+	defer end(&err)
+
+	if err := s.validateEntity(entity); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, entity)
+}
+
+// Get retrieves an entity by ID
+func (s *crudService[T]) Get(ctx context.Context, id uint) (entity *T, err error) {
+	ctx, end := tracing.StartSpan(ctx, s.spanPrefix+"Get")
+	defer end(&err)
+
+	return s.repo.Get(ctx, id)
+}
+
+// Update validates and stores an existing entity
+func (s *crudService[T]) Update(ctx context.Context, entity *T) (err error) {
+	ctx, end := tracing.StartSpan(ctx, s.spanPrefix+"Update")
+	defer end(&err)
+
+	if err := s.validateEntity(entity); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, entity)
+}
+
+// Delete deletes an entity, failing if it changed since it was read
+func (s *crudService[T]) Delete(ctx context.Context, entity *T) (err error) {
+	ctx, end := tracing.StartSpan(ctx, s.spanPrefix+"Delete")
+	defer end(&err)
+
+	return s.repo.Delete(ctx, entity)
+}
+
+// List retrieves a filtered, sorted and paginated list
+func (s *crudService[T]) List(ctx context.Context, spec *query.Spec, offset, limit int) (entities []T, total int64, err error) {
+	ctx, end := tracing.StartSpan(ctx, s.spanPrefix+"List")
+	defer end(&err)
+
+	return s.repo.List(ctx, spec, offset, limit)
+}
+
+// ListPage retrieves a filtered page using cursor pagination
+func (s *crudService[T]) ListPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (page *pagination.Page[T], err error) {
+	ctx, end := tracing.StartSpan(ctx, s.spanPrefix+"ListPage")
+	defer end(&err)
+
+	return s.repo.ListPage(ctx, spec, params)
+}
+
+// validateEntity checks the entity's validate tags
+func (s *crudService[T]) validateEntity(entity *T) error {
+	if err := s.validate.Struct(entity); err != nil {
+		return apperrors.Validation("The provided data is invalid. Please check your input.", nil).Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+)
+
+// fakeRefreshTokenRepo is an in-memory RefreshTokenRepository. Its
+// conditional Revoke behaves like the SQL one, so concurrent refreshes of a
+// token can be exercised.
+type fakeRefreshTokenRepo struct {
+	mu     sync.Mutex
+	tokens []*models.RefreshToken
+	nextID uint
+
+	// beforeRevoke, when set, runs before every Revoke outside the lock
+	beforeRevoke func()
+}
+
+func (r *fakeRefreshTokenRepo) Create(_ context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	token.ID = r.nextID
+	stored := *token
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByHash(_ context.Context, tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, repositories.ErrRefreshTokenNotFound
+}
+
+func (r *fakeRefreshTokenRepo) Revoke(_ context.Context, id uint) (bool, error) {
+	if r.beforeRevoke != nil {
+		r.beforeRevoke()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.ID == id && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeFamily(_ context.Context, familyID string) error {
+	return r.revokeWhere(func(token *models.RefreshToken) bool { return token.FamilyID == familyID })
+}
+
+func (r *fakeRefreshTokenRepo) RevokeAllForUser(_ context.Context, userID uint) error {
+	return r.revokeWhere(func(token *models.RefreshToken) bool { return token.UserID == userID })
+}
+
+func (r *fakeRefreshTokenRepo) revokeWhere(match func(*models.RefreshToken) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// active returns how many tokens have not been revoked
+func (r *fakeRefreshTokenRepo) active() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, token := range r.tokens {
+		if token.RevokedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeTxManager runs units of work without a transaction
+type fakeTxManager struct{}
+
+func (fakeTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -1,19 +1,25 @@
 package services
 
 import (
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
 	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+	"github.com/go-playground/validator/v10"
 )
 
 // Services holds all service instances
 type Services struct {
 	User UserService
+	Auth AuthService
 	// Add more services here as you create them
 }
 
 // NewServices creates a new Services instance with all services
-func NewServices(repos *repositories.Repositories) *Services {
+func NewServices(repos *repositories.Repositories, cfg *config.Config, validate *validator.Validate) *Services {
+	userService := NewUserService(repos.User, repos.Role, repos.RefreshToken, repos.Tx, validate)
+
 	return &Services{
-		User: NewUserService(repos.User),
+		User: userService,
+		Auth: NewAuthService(userService, repos.User, repos.RefreshToken, cfg.JWT),
 		// Add more services here as you create them
 	}
 }
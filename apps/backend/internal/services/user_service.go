@@ -3,49 +3,104 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
 	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
 	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+	"github.com/albuquerquewizard/monorepo/backend/internal/tracing"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Authentication errors returned by AuthenticateUser
+var (
+	ErrInvalidCredentials = apperrors.Unauthorized("invalid_credentials", "Invalid username or password")
+	ErrUserInactive       = apperrors.Forbidden("user_inactive", "User account is deactivated")
+)
+
+// dummyPasswordHash is compared against when no user has the username, so
+// that unknown usernames take as long to reject as wrong passwords. Its cost
+// must match bcrypt.DefaultCost.
+const dummyPasswordHash = "$2a$10$GgbQdulsX/szKrCyt/9vKOKSIFeGUGqwVtOmy/QtT6C6fbMO2ApnO"
+
+// Password errors returned by VerifyPassword
+var (
+	ErrCurrentPasswordRequired = apperrors.Validation("The current password is required to change your password",
+		map[string]string{"current_password": "This field is required"})
+	ErrIncorrectPassword = apperrors.Validation("The current password is incorrect",
+		map[string]string{"current_password": "Incorrect password"})
+)
+
 // UserService defines the interface for user business logic
 type UserService interface {
-	CreateUser(ctx context.Context, user *models.User) error
+	CreateUser(ctx context.Context, user *models.User, roleNames ...string) error
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	GetUserByIDWithTrashed(ctx context.Context, id uint) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User) error
-	DeleteUser(ctx context.Context, id uint) error
-	ListUsers(ctx context.Context, offset, limit int) ([]models.User, int64, error)
+	DeleteUser(ctx context.Context, user *models.User) error
+	RestoreUser(ctx context.Context, user *models.User) error
+	HardDeleteUser(ctx context.Context, user *models.User) error
+	PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
+	ListUsers(ctx context.Context, spec *query.Spec, offset, limit int) ([]models.User, int64, error)
+	ListUsersPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (*pagination.Page[models.User], error)
 	AuthenticateUser(ctx context.Context, username, password string) (*models.User, error)
+	VerifyPassword(ctx context.Context, user *models.User, password string) error
+	ChangePassword(ctx context.Context, id uint, password string) error
+	AssignRole(ctx context.Context, id uint, roleName string) error
 }
 
 // userService implements UserService
 type userService struct {
-	userRepo repositories.UserRepository
-	validate *validator.Validate
+	userRepo         repositories.UserRepository
+	roleRepo         repositories.RoleRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	tx               repositories.TxManager
+	validate         *validator.Validate
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo repositories.UserRepository) UserService {
+func NewUserService(
+	userRepo repositories.UserRepository,
+	roleRepo repositories.RoleRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	tx repositories.TxManager,
+	validate *validator.Validate,
+) UserService {
 	return &userService{
-		userRepo: userRepo,
-		validate: validator.New(),
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		tx:               tx,
+		validate:         validate,
 	}
 }
 
-// CreateUser creates a new user with validation and password hashing
-func (s *userService) CreateUser(ctx context.Context, user *models.User) error {
+// CreateUser creates a new user with validation and password hashing. The
+// named roles are granted in the same transaction, so either the user is
+// created with all of them or nothing is written.
+func (s *userService) CreateUser(ctx context.Context, user *models.User, roleNames ...string) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.CreateUser")
+	defer end(&err)
+
 	// Validate user data
 	if err := s.validate.Struct(user); err != nil {
+		return apperrors.Validation("Invalid user data", nil).Wrap(err)
+	}
+	if err := s.validatePassword(user.Password); err != nil {
 		return err
 	}
 
 	// Check if username already exists
 	existingUser, err := s.userRepo.GetByUsername(ctx, user.Username)
 	if err == nil && existingUser != nil {
-		return errors.New("username already exists")
+		return repositories.ErrUsernameTaken
+	}
+	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+		return err
 	}
 
 	// Hash password
@@ -55,25 +110,52 @@ func (s *userService) CreateUser(ctx context.Context, user *models.User) error {
 	}
 	user.Password = string(hashedPassword)
 
-	// Create user
-	return s.userRepo.Create(ctx, user)
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		for _, roleName := range roleNames {
+			if err := s.addRole(ctx, user, roleName); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetUserByID retrieves a user by ID
-func (s *userService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+func (s *userService) GetUserByID(ctx context.Context, id uint) (user *models.User, err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.GetUserByID")
+	defer end(&err)
+
 	return s.userRepo.GetByID(ctx, id)
 }
 
+// GetUserByIDWithTrashed retrieves a user by ID, including soft-deleted users
+func (s *userService) GetUserByIDWithTrashed(ctx context.Context, id uint) (user *models.User, err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.GetUserByIDWithTrashed")
+	defer end(&err)
+
+	return s.userRepo.GetByIDWithTrashed(ctx, id)
+}
+
 // GetUserByUsername retrieves a user by username
-func (s *userService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+func (s *userService) GetUserByUsername(ctx context.Context, username string) (user *models.User, err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.GetUserByUsername")
+	defer end(&err)
+
 	return s.userRepo.GetByUsername(ctx, username)
 }
 
-// UpdateUser updates an existing user
-func (s *userService) UpdateUser(ctx context.Context, user *models.User) error {
+// UpdateUser updates an existing user. Changing the password signs the user
+// out everywhere by revoking their refresh tokens.
+func (s *userService) UpdateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.UpdateUser")
+	defer end(&err)
+
 	// Validate user data
 	if err := s.validate.Struct(user); err != nil {
-		return err
+		return apperrors.Validation("Invalid user data", nil).Wrap(err)
 	}
 
 	// Check if user exists
@@ -81,49 +163,191 @@ func (s *userService) UpdateUser(ctx context.Context, user *models.User) error {
 	if err != nil {
 		return err
 	}
+	if existingUser.Version != user.Version {
+		return repositories.ErrUserModified
+	}
 
-	// If password is being updated, hash it
+	// If password is being updated, validate and hash it
 	if user.Password != "" && user.Password != existingUser.Password {
+		if err := s.validatePassword(user.Password); err != nil {
+			return err
+		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
 			return err
 		}
 		user.Password = string(hashedPassword)
-	} else {
-		// Keep existing password if not updated
-		user.Password = existingUser.Password
+		return s.updatePassword(ctx, user)
 	}
 
+	// Keep existing password if not updated
+	user.Password = existingUser.Password
 	return s.userRepo.Update(ctx, user)
 }
 
-// DeleteUser deletes a user by ID
-func (s *userService) DeleteUser(ctx context.Context, id uint) error {
-	return s.userRepo.Delete(ctx, id)
+// DeleteUser deletes a user, failing with ErrUserModified if it changed since it was read
+func (s *userService) DeleteUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.DeleteUser")
+	defer end(&err)
+
+	return s.userRepo.Delete(ctx, user)
+}
+
+// RestoreUser undeletes a soft-deleted user
+func (s *userService) RestoreUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.RestoreUser")
+	defer end(&err)
+
+	if !user.DeletedAt.Valid {
+		return repositories.ErrUserNotDeleted
+	}
+	return s.userRepo.Restore(ctx, user)
 }
 
-// ListUsers retrieves a paginated list of users
-func (s *userService) ListUsers(ctx context.Context, offset, limit int) ([]models.User, int64, error) {
-	return s.userRepo.List(ctx, offset, limit)
+// HardDeleteUser permanently deletes a user, failing with ErrUserModified if it changed since it was read
+func (s *userService) HardDeleteUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.HardDeleteUser")
+	defer end(&err)
+
+	return s.userRepo.HardDelete(ctx, user)
 }
 
-// AuthenticateUser authenticates a user with username and password
-func (s *userService) AuthenticateUser(ctx context.Context, username, password string) (*models.User, error) {
+// PurgeDeletedUsers permanently deletes users soft-deleted before the given
+// time and returns how many were removed
+func (s *userService) PurgeDeletedUsers(ctx context.Context, before time.Time) (purged int64, err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.PurgeDeletedUsers")
+	defer end(&err)
+
+	return s.userRepo.PurgeDeleted(ctx, before)
+}
+
+// ListUsers retrieves a filtered, sorted and paginated list of users
+func (s *userService) ListUsers(ctx context.Context, spec *query.Spec, offset, limit int) (users []models.User, total int64, err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.ListUsers")
+	defer end(&err)
+
+	return s.userRepo.List(ctx, spec, offset, limit)
+}
+
+// ListUsersPage retrieves a filtered page of users using cursor pagination
+func (s *userService) ListUsersPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (page *pagination.Page[models.User], err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.ListUsersPage")
+	defer end(&err)
+
+	return s.userRepo.ListPage(ctx, spec, params)
+}
+
+// AuthenticateUser authenticates a user with username and password. Unknown
+// usernames and wrong passwords fail alike, with ErrInvalidCredentials.
+func (s *userService) AuthenticateUser(ctx context.Context, username, password string) (user *models.User, err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.AuthenticateUser")
+	defer end(&err)
+
 	// Get user by username
-	user, err := s.userRepo.GetByUsername(ctx, username)
+	user, err = s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Verify password
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
 	}
 
-	// Check if user is active
+	// Only reveal that the account is deactivated to someone who knows its password
 	if !user.IsActive {
-		return nil, errors.New("user account is deactivated")
+		return nil, ErrUserInactive
 	}
 
-	// Verify password
+	return user, nil
+}
+
+// VerifyPassword checks password against a user's stored password hash, as
+// users must confirm their current password before changing it
+func (s *userService) VerifyPassword(ctx context.Context, user *models.User, password string) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.VerifyPassword")
+	defer end(&err)
+
+	if password == "" {
+		return ErrCurrentPasswordRequired
+	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, errors.New("invalid credentials")
+		return ErrIncorrectPassword
 	}
+	return nil
+}
 
-	return user, nil
+// ChangePassword hashes and stores a new password for a user and revokes
+// their refresh tokens
+func (s *userService) ChangePassword(ctx context.Context, id uint, password string) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.ChangePassword")
+	defer end(&err)
+
+	if err := s.validatePassword(password); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashedPassword)
+
+	return s.updatePassword(ctx, user)
+}
+
+// updatePassword saves a user whose password hash changed and revokes their
+// refresh tokens in the same transaction, so that sessions started with the
+// old password cannot be refreshed
+func (s *userService) updatePassword(ctx context.Context, user *models.User) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.refreshTokenRepo.RevokeAllForUser(ctx, user.ID)
+	})
+}
+
+// AssignRole grants the named role to a user
+func (s *userService) AssignRole(ctx context.Context, id uint, roleName string) (err error) {
+	ctx, end := tracing.StartSpan(ctx, "UserService.AssignRole")
+	defer end(&err)
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.addRole(ctx, user, roleName)
+}
+
+// addRole grants the named role to user, updating its roles and version
+func (s *userService) addRole(ctx context.Context, user *models.User, roleName string) error {
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.AddRole(ctx, user, role)
+}
+
+// validatePassword checks a plaintext password against the password strength rule
+func (s *userService) validatePassword(password string) error {
+	input := struct {
+		Password string `json:"password" validate:"required,max=72,password"`
+	}{Password: password}
+
+	if err := s.validate.Struct(input); err != nil {
+		return apperrors.Validation("Invalid password", nil).Wrap(err)
+	}
+	return nil
 }
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/testutil"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestUserService creates a user service for one user with an active
+// refresh token, backed by in-memory repositories
+func newTestUserService(t *testing.T) (*userService, *testutil.UserRepository, *fakeRefreshTokenRepo) {
+	t.Helper()
+
+	validate := validator.New()
+	for _, tag := range []string{"username", "password"} {
+		if err := validate.RegisterValidation(tag, func(validator.FieldLevel) bool { return true }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users := testutil.NewUserRepository(&models.User{
+		BaseModel: models.BaseModel{ID: 1, Version: 1},
+		Username:  "alice",
+		Password:  "old-hash",
+		IsActive:  true,
+	})
+	tokens := &fakeRefreshTokenRepo{}
+	for _, userID := range []uint{1, 2} {
+		if err := tokens.Create(context.Background(), &models.RefreshToken{
+			UserID:    userID,
+			TokenHash: hashRefreshToken(time.Now().String()),
+			FamilyID:  "family",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &userService{
+		userRepo:         users,
+		refreshTokenRepo: tokens,
+		tx:               fakeTxManager{},
+		validate:         validate,
+	}, users, tokens
+}
+
+func TestPasswordChangeRevokesRefreshTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *userService, user *models.User) error
+		// revoked reports whether alice's refresh token should be revoked
+		revoked bool
+	}{
+		{
+			name: "ChangePassword",
+			change: func(s *userService, user *models.User) error {
+				return s.ChangePassword(context.Background(), user.ID, "NewSecret123")
+			},
+			revoked: true,
+		},
+		{
+			name: "UpdateUser with a new password",
+			change: func(s *userService, user *models.User) error {
+				user.Password = "NewSecret123"
+				return s.UpdateUser(context.Background(), user)
+			},
+			revoked: true,
+		},
+		{
+			name: "UpdateUser without a password",
+			change: func(s *userService, user *models.User) error {
+				user.Password = ""
+				user.FirstName = "Alice"
+				return s.UpdateUser(context.Background(), user)
+			},
+			revoked: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, users, tokens := newTestUserService(t)
+			user, _ := users.GetByID(context.Background(), 1)
+
+			if err := tt.change(s, user); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			if got := tokens.tokens[0].IsRevoked(); got != tt.revoked {
+				t.Errorf("refresh token revoked = %v, want %v", got, tt.revoked)
+			}
+			if tokens.tokens[1].IsRevoked() {
+				t.Error("another user's refresh token was revoked")
+			}
+			if stored, _ := users.GetByID(context.Background(), 1); tt.revoked == (stored.Password == "old-hash") {
+				t.Errorf("stored password hash = %q, changed = %v", stored.Password, tt.revoked)
+			}
+		})
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Password: string(hash)}
+
+	tests := []struct {
+		name     string
+		password string
+		want     error
+	}{
+		{"correct", testPassword, nil},
+		{"incorrect", "Wrong1234", ErrIncorrectPassword},
+		{"missing", "", ErrCurrentPasswordRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &userService{}
+			if err := s.VerifyPassword(context.Background(), user, tt.password); !errors.Is(err, tt.want) {
+				t.Errorf("VerifyPassword() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	// Unknown usernames are only as slow to reject as wrong passwords if the
+	// dummy hash costs as much as real ones
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil {
+		t.Fatalf("dummy hash is not a bcrypt hash: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+}
@@ -0,0 +1,120 @@
+// Package testutil provides shared helpers for tests, such as the in-memory
+// repositories and HTTP helpers used by the tests the scaffold generates.
+package testutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/pagination"
+	"github.com/albuquerquewizard/monorepo/backend/internal/query"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+)
+
+// MemoryRepository is an in-memory repositories.Repository. Like the GORM
+// repository it assigns IDs, timestamps and versions and guards writes by
+// version, but it ignores filters and sorts and lists entities in ID order.
+type MemoryRepository[T any, PT repositories.Model[T]] struct {
+	mu     sync.Mutex
+	errs   repositories.ResourceErrors
+	nextID uint
+	rows   map[uint]T
+}
+
+// NewMemoryRepository creates an empty repository returning errs
+func NewMemoryRepository[T any, PT repositories.Model[T]](errs repositories.ResourceErrors) *MemoryRepository[T, PT] {
+	return &MemoryRepository[T, PT]{errs: errs, rows: map[uint]T{}}
+}
+
+// Create stores a new entity
+func (r *MemoryRepository[T, PT]) Create(_ context.Context, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	base := PT(entity).Base()
+	base.ID = r.nextID
+	base.Version = 1
+	base.CreatedAt = time.Now()
+	base.UpdatedAt = base.CreatedAt
+	r.rows[base.ID] = *entity
+	return nil
+}
+
+// Get returns a copy of the entity with the given ID
+func (r *MemoryRepository[T, PT]) Get(_ context.Context, id uint) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entity, ok := r.rows[id]
+	if !ok {
+		return nil, r.errs.NotFound
+	}
+	return &entity, nil
+}
+
+// Update replaces an entity, provided its version matches, and increments the version
+func (r *MemoryRepository[T, PT]) Update(_ context.Context, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	base := PT(entity).Base()
+	if err := r.check(base.ID, base.Version); err != nil {
+		return err
+	}
+	base.Version++
+	base.UpdatedAt = time.Now()
+	r.rows[base.ID] = *entity
+	return nil
+}
+
+// Delete removes an entity, provided its version matches
+func (r *MemoryRepository[T, PT]) Delete(_ context.Context, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	base := PT(entity).Base()
+	if err := r.check(base.ID, base.Version); err != nil {
+		return err
+	}
+	delete(r.rows, base.ID)
+	return nil
+}
+
+// List returns a page of entities in ID order and the total count
+func (r *MemoryRepository[T, PT]) List(_ context.Context, _ *query.Spec, offset, limit int) ([]T, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entities := make([]T, 0, len(r.rows))
+	for id := uint(1); id <= r.nextID; id++ {
+		if entity, ok := r.rows[id]; ok {
+			entities = append(entities, entity)
+		}
+	}
+	total := int64(len(entities))
+	entities = entities[min(offset, len(entities)):]
+	return entities[:min(limit, len(entities))], total, nil
+}
+
+// ListPage returns the first page of entities; cursors are ignored
+func (r *MemoryRepository[T, PT]) ListPage(ctx context.Context, spec *query.Spec, params pagination.KeysetParams) (*pagination.Page[T], error) {
+	entities, _, err := r.List(ctx, spec, 0, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Page[T]{Items: entities}, nil
+}
+
+// check reports whether the entity exists at the given version
+func (r *MemoryRepository[T, PT]) check(id, version uint) error {
+	stored, ok := r.rows[id]
+	if !ok {
+		return r.errs.NotFound
+	}
+	if PT(&stored).Base().Version != version {
+		return r.errs.Modified
+	}
+	return nil
+}
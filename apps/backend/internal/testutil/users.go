@@ -0,0 +1,69 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/models"
+	"github.com/albuquerquewizard/monorepo/backend/internal/repositories"
+)
+
+// UserRepository is an in-memory repositories.UserRepository holding the
+// methods the service and middleware tests use; the others panic through the
+// nil embedded interface. Tests may change Users directly to set up a case.
+type UserRepository struct {
+	repositories.UserRepository
+
+	mu    sync.Mutex
+	Users map[uint]*models.User
+}
+
+// NewUserRepository creates a repository holding users
+func NewUserRepository(users ...*models.User) *UserRepository {
+	repo := &UserRepository{Users: make(map[uint]*models.User)}
+	for _, user := range users {
+		repo.Users[user.ID] = user
+	}
+	return repo
+}
+
+// GetByID returns a copy of the user with the given ID
+func (r *UserRepository) GetByID(_ context.Context, id uint) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.Users[id]
+	if !ok {
+		return nil, repositories.ErrUserNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+// GetByUsername returns a copy of the user with the given username
+func (r *UserRepository) GetByUsername(_ context.Context, username string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.Users {
+		if user.Username == username {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, repositories.ErrUserNotFound
+}
+
+// Update stores a copy of user and bumps its version
+func (r *UserRepository) Update(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.Users[user.ID]; !ok {
+		return repositories.ErrUserNotFound
+	}
+	user.Version++
+	stored := *user
+	r.Users[user.ID] = &stored
+	return nil
+}
@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// querySpanKey stores the span of a query on the GORM statement
+const querySpanKey = "tracing:span"
+
+// rowsAffectedKey records how many rows a write changed
+const rowsAffectedKey = attribute.Key("db.rows_affected")
+
+// Plugin is a GORM plugin that records a client span for every query run
+// with a context carrying a span, which repositories get by binding queries
+// to the request context. Queries outside a trace, such as migrations, are
+// not recorded. Spans hold the SQL with placeholders but never the query
+// arguments, which may be secrets such as password hashes and tokens.
+type Plugin struct {
+	// Database is recorded as the namespace of the queries
+	Database string
+}
+
+// NewPlugin creates the tracing plugin for the named database
+func NewPlugin(database string) *Plugin {
+	return &Plugin{Database: database}
+}
+
+// Name implements gorm.Plugin
+func (p *Plugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin by registering the query callbacks
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	tracer := otel.Tracer(instrumentationName)
+
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("tracing:before_create", p.startSpan(tracer, "INSERT")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", endSpan("INSERT")),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", p.startSpan(tracer, "SELECT")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", endSpan("SELECT")),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", p.startSpan(tracer, "UPDATE")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", endSpan("UPDATE")),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", p.startSpan(tracer, "DELETE")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan("DELETE")),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", p.startSpan(tracer, "SELECT")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", endSpan("SELECT")),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", p.startSpan(tracer, "RAW")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan("RAW")),
+	)
+}
+
+// startSpan returns a callback starting a query span below the statement context's span
+func (p *Plugin) startSpan(tracer trace.Tracer, operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+
+		attrs := []attribute.KeyValue{
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+		}
+		if p.Database != "" {
+			attrs = append(attrs, semconv.DBNamespace(p.Database))
+		}
+		_, span := tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+// endSpan returns a callback that names the query span after the table,
+// records the SQL, the row count and any error, and ends the span
+func endSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(querySpanKey)
+		if !ok {
+			return
+		}
+		span, ok := value.(trace.Span)
+		if !ok {
+			return
+		}
+		defer span.End()
+
+		if table := db.Statement.Table; table != "" {
+			span.SetName(operation + " " + table)
+			span.SetAttributes(semconv.DBCollectionName(table))
+		}
+		span.SetAttributes(
+			semconv.DBQueryText(db.Statement.SQL.String()),
+			rowsAffectedKey.Int64(db.RowsAffected),
+		)
+
+		// A missing record is an expected outcome, not a failed query
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+	}
+}
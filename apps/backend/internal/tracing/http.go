@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"strings"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware continues the trace from the request's traceparent header, or
+// starts a new one, and wraps the request in a server span named after the
+// route template. The span's context becomes the request's user context, so
+// handlers must pass c.UserContext() on to services. It must run outside
+// middleware.ErrorResponder, which records handler errors on the span, so
+// that the span records the status that is sent.
+func Middleware() fiber.Handler {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+
+		// Fiber strings point into reused buffers, so values kept by the span are copied
+		method := strings.Clone(c.Method())
+		ctx, span := tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(strings.Clone(c.Path())),
+				semconv.URLScheme(strings.Clone(c.Protocol())),
+				semconv.ClientAddress(strings.Clone(c.IP())),
+				semconv.UserAgentOriginal(strings.Clone(c.Get(fiber.HeaderUserAgent))),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		if route := utils.RouteTemplate(c); route != utils.UnmatchedRoute {
+			span.SetName(method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		status := c.Response().StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		return err
+	}
+}
+
+// headerCarrier reads propagation headers from a Fiber request
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+// Get returns the value of a request header
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+// Set is not supported; the carrier is only used to extract incoming context
+func (h headerCarrier) Set(string, string) {}
+
+// Keys lists the request header names
+func (h headerCarrier) Keys() []string {
+	headers := h.c.GetReqHeaders()
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	return keys
+}
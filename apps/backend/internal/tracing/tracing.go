@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"github.com/albuquerquewizard/monorepo/backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by the application
+const instrumentationName = "github.com/albuquerquewizard/monorepo/backend"
+
+// ShutdownFunc flushes buffered spans and releases the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the W3C trace context propagator and, unless the exporter
+// is "none", a global tracer provider exporting spans as configured. Without
+// an exporter incoming trace IDs are still propagated to logs and responses,
+// but no spans are recorded. The returned function must be called on
+// shutdown so that buffered spans are not lost.
+func Setup(ctx context.Context, cfg *config.Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch cfg.Tracing.Exporter {
+	case config.TracingExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case config.TracingExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case config.TracingExporterStdout:
+		var out io.Writer = os.Stdout
+		if cfg.Tracing.File != "" {
+			file, openErr := os.OpenFile(cfg.Tracing.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+			if openErr != nil {
+				return nil, fmt.Errorf("opening trace file: %w", openErr)
+			}
+			out, closer = file, file
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected otlp, stdout or none", cfg.Tracing.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", cfg.Tracing.Exporter, err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.App.Name),
+			semconv.DeploymentEnvironmentNameKey.String(cfg.App.Env),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// StartSpan starts a span named name as a child of the span carried by ctx.
+// The returned function ends the span, recording the error err points to;
+// functions with a named error result defer it:
+//
+//	ctx, end := tracing.StartSpan(ctx, "UserService.UpdateUser")
+//	defer end(&err)
+func StartSpan(ctx context.Context, name string) (context.Context, func(err *error)) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name)
+	return ctx, func(err *error) {
+		if *err != nil {
+			recordError(span, *err)
+		}
+		span.End()
+	}
+}
+
+// RecordError records err on the span carried by ctx
+func RecordError(ctx context.Context, err error) {
+	recordError(trace.SpanFromContext(ctx), err)
+}
+
+// recordError records err on span. Only internal errors mark the span as
+// failed; domain errors such as not found are the caller's fault and are
+// recorded as events.
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	if apperrors.KindOf(err) == apperrors.KindInternal {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// TraceID returns the ID of the trace ctx belongs to, or "" outside a trace
+func TraceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return ""
+}
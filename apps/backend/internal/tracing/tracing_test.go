@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/albuquerquewizard/monorepo/backend/internal/apperrors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	tests := []struct {
+		name       string
+		err        error
+		wantEvents int
+		wantStatus codes.Code
+	}{
+		{"success", nil, 0, codes.Unset},
+		{"domain error", apperrors.NotFound("user_not_found", "User not found"), 1, codes.Unset},
+		{"internal error", errors.New("connection refused"), 1, codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parentCtx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+			defer parent.End()
+
+			func() (err error) {
+				ctx, end := StartSpan(parentCtx, "UserService.GetUserByID")
+				defer end(&err)
+
+				if got := trace.SpanFromContext(ctx).SpanContext(); got.SpanID() == parent.SpanContext().SpanID() {
+					t.Error("StartSpan() did not start a new span")
+				}
+				return tt.err
+			}()
+
+			spans := recorder.Ended()
+			span := spans[len(spans)-1]
+			if span.Name() != "UserService.GetUserByID" {
+				t.Fatalf("last ended span = %q, want UserService.GetUserByID", span.Name())
+			}
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Error("span is not a child of the span in the context")
+			}
+			if len(span.Events()) != tt.wantEvents {
+				t.Errorf("span has %d events, want %d", len(span.Events()), tt.wantEvents)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+		})
+	}
+}
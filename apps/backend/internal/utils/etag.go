@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionETag returns the strong entity tag for version of the resource with the given ID
+func VersionETag(id, version uint) string {
+	return fmt.Sprintf(`"%d-%d"`, id, version)
+}
+
+// MatchesIfMatch reports whether an If-Match header value matches etag. Per
+// RFC 9110 If-Match uses strong comparison, so weak tags never match.
+func MatchesIfMatch(header, etag string) bool {
+	for _, tag := range splitETags(header) {
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesIfNoneMatch reports whether an If-None-Match header value matches
+// etag. If-None-Match uses weak comparison, so W/ prefixes are ignored.
+func MatchesIfNoneMatch(header, etag string) bool {
+	for _, tag := range splitETags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitETags splits a comma separated list of entity tags
+func splitETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// MergePatchContentType is the media type of JSON Merge Patch documents (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+// ErrInvalidMergePatch is returned when a merge patch is not a JSON object
+var ErrInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to the JSON object doc and
+// returns the patched document. Members set to null in the patch are removed,
+// objects are merged recursively and every other value replaces the original.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var patchValue map[string]interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil || patchValue == nil {
+		return nil, ErrInvalidMergePatch
+	}
+
+	var target map[string]interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeObjects(target, patchValue))
+}
+
+// mergeObjects merges patch into target following RFC 7396
+func mergeObjects(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		patchObject, isObject := value.(map[string]interface{})
+		if !isObject {
+			target[key] = value
+			continue
+		}
+
+		targetObject, _ := target[key].(map[string]interface{})
+		target[key] = mergeObjects(targetObject, patchObject)
+	}
+	return target
+}
@@ -1,7 +1,12 @@
 package utils
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	fiberutils "github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
 )
 
 // Response represents a standard API response
@@ -32,8 +37,12 @@ func SuccessResponseWithMeta(c *fiber.Ctx, message string, data interface{}, met
 	})
 }
 
-// ErrorResponse returns an error response
+// ErrorResponse returns an error response. Server errors always carry the
+// generic status text, so that internal error details never reach clients.
 func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
+	if statusCode >= fiber.StatusInternalServerError {
+		message = fiberutils.StatusMessage(statusCode)
+	}
 	return c.Status(statusCode).JSON(Response{
 		Success: false,
 		Error:   message,
@@ -42,23 +51,84 @@ func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
 
 // ValidationErrorResponse returns a validation error response
 func ValidationErrorResponse(c *fiber.Ctx, errors map[string]string) error {
-	return c.Status(fiber.StatusBadRequest).JSON(Response{
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(Response{
 		Success: false,
 		Error:   "Validation failed",
 		Data:    errors,
 	})
 }
 
-// PaginatedResponse returns a paginated response with metadata
-func PaginatedResponse(c *fiber.Ctx, message string, data interface{}, total, page, limit int) error {
-	meta := fiber.Map{
-		"pagination": fiber.Map{
-			"total": total,
-			"page":  page,
-			"limit": limit,
-			"pages": (total + limit - 1) / limit, // Calculate total pages
-		},
+// Pagination describes where a page sits in a list. It is the single
+// pagination contract returned in meta.pagination by every list endpoint.
+type Pagination struct {
+	Mode           string `json:"mode"`
+	Limit          int    `json:"limit"`
+	Total          *int64 `json:"total,omitempty"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+	Page           int    `json:"page,omitempty"`
+	Pages          int    `json:"pages,omitempty"`
+	Offset         *int   `json:"offset,omitempty"`
+	NextCursor     string `json:"next_cursor,omitempty"`
+	PrevCursor     string `json:"prev_cursor,omitempty"`
+	HasNext        bool   `json:"has_next"`
+	HasPrev        bool   `json:"has_prev"`
+
+	// NextLink and PrevLink are rendered as Link headers, nil at either end of the list
+	NextLink *PageLink `json:"-"`
+	PrevLink *PageLink `json:"-"`
+}
+
+// PageLink describes how to request an adjacent page: the query parameters to
+// set and the ones to remove from the current request URL
+type PageLink struct {
+	Set    map[string]string
+	Remove []string
+}
+
+// PaginatedResponse returns a list response with pagination metadata under
+// meta.pagination, any extra metadata such as applied filters alongside it, and
+// RFC 8288 Link headers for the next and previous pages
+func PaginatedResponse(c *fiber.Ctx, message string, data interface{}, pagination Pagination, extra map[string]interface{}) error {
+	meta := fiber.Map{}
+	for key, value := range extra {
+		meta[key] = value
+	}
+	meta["pagination"] = pagination
+
+	var links []string
+	if pagination.NextLink != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(c, pagination.NextLink)))
+	}
+	if pagination.PrevLink != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(c, pagination.PrevLink)))
+	}
+	if len(links) > 0 {
+		c.Set(fiber.HeaderLink, strings.Join(links, ", "))
 	}
 
 	return SuccessResponseWithMeta(c, message, data, meta)
 }
+
+// TotalPages returns the number of pages of size limit needed for total items
+func TotalPages(total int64, limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return int((total + int64(limit) - 1) / int64(limit))
+}
+
+// pageURL returns the current request URL with the link's query changes applied
+func pageURL(c *fiber.Ctx, link *PageLink) string {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+	c.Request().URI().QueryArgs().CopyTo(args)
+
+	for _, name := range link.Remove {
+		args.Del(name)
+	}
+	for name, value := range link.Set {
+		args.Set(name, value)
+	}
+
+	return c.BaseURL() + c.Path() + "?" + args.String()
+}
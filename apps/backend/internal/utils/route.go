@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UnmatchedRoute stands in for the route template of requests no route
+// handled, so that arbitrary paths never become metric labels or span names
+const UnmatchedRoute = "unmatched"
+
+// RouteTemplate returns the path template of the route that handled the
+// request, such as /api/users/:id. The result is safe to keep after the
+// request completes.
+func RouteTemplate(c *fiber.Ctx) string {
+	path := c.Route().Path
+	// Requests no endpoint handled end in middleware mounted at the root,
+	// such as the 404 handler
+	if path == "/" && c.Path() != "/" {
+		return UnmatchedRoute
+	}
+	return strings.Clone(path)
+}